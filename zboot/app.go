@@ -3,15 +3,28 @@ package zboot
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/spf13/pflag"
+
 	"github.com/yyliziqiu/zlib/zconfig"
+	"github.com/yyliziqiu/zlib/zhealth"
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
+	"github.com/yyliziqiu/zlib/zvalid"
 )
 
+// DefaultWaitTime 应用退出时等待任务结束的默认最长时长
+const DefaultWaitTime = 10 * time.Second
+
+var _redactHookOnce sync.Once
+
 type App struct {
 	// app 名称
 	Name string
@@ -25,16 +38,37 @@ type App struct {
 	// 日志目录路径
 	LogdirPath string
 
-	// 全局配置
+	// 环境变量前缀，如 APP 时环境变量 APP_LOG_LEVEL 覆盖配置项 Log.Level，为空时不读取环境变量
+	EnvPrefix string
+
+	// 全局配置，加载后依次执行 zvalid 校验、ICheck 和 IDefault，校验和 ICheck 时默认值尚未填充
 	Config any
 
+	// 监听配置文件变化并重新加载，通过 GetConfig 获取最新配置
+	WatchConfig bool
+
 	// 模块
 	InitFuncs   InitFuncs
 	BootFuncs   BootFuncs
 	InitFuncsCb func() InitFuncs
 	BootFuncsCb func() BootFuncs
 
+	// 带依赖关系和生命周期的模块，在 InitFuncs 和 BootFuncs 之后执行
+	Modules   Modules
+	ModulesCb func() Modules
+
 	hasCallInitFuncs bool
+
+	modules Modules // 已排序的模块
+	inited  Modules // 已初始化的模块，退出或启动失败时按相反顺序停止
+	started Modules // 已启动的模块
+
+	loader      *zconfig.Loader
+	watcher     *zconfig.Watcher
+	subscribers []func(old any, new any)
+
+	flags     *pflag.FlagSet // 命令行参数，覆盖配置文件
+	overrides []string       // 命令行 --set 参数
 }
 
 // Init app
@@ -48,24 +82,15 @@ func (app *App) Init() (err error) {
 
 func (app *App) InitConfig() (err error) {
 	// 加载配置文件
-	err = zconfig.Init(app.ConfigPath, app.Config)
+	err = app.loadConfig()
 	if err != nil {
 		return fmt.Errorf("init config error [%v]", err)
 	}
 
-	// 检查配置是否正确
-	icheck, ok := app.Config.(ICheck)
-	if ok {
-		err = icheck.Check()
-		if err != nil {
-			return err
-		}
-	}
-
-	// 为配置项设置默认值
-	idefault, ok := app.Config.(IDefault)
-	if ok {
-		idefault.Default()
+	// 检查配置是否正确，并为配置项设置默认值
+	err = checkConfig(app.Config)
+	if err != nil {
+		return err
 	}
 
 	// 初始化日志
@@ -81,10 +106,89 @@ func (app *App) InitConfig() (err error) {
 	if err != nil {
 		return fmt.Errorf("init log error [%v]", err)
 	}
+	_redactHookOnce.Do(func() {
+		zlog.AddHook(zconfig.RedactHook{})
+	})
+
+	return nil
+}
+
+func (app *App) loadConfig() (err error) {
+	if app.watcher != nil {
+		return nil
+	}
+
+	app.loader = &zconfig.Loader{
+		Path:      app.ConfigPath,
+		EnvPrefix: app.EnvPrefix,
+		Flags:     app.flags,
+		Overrides: app.overrides,
+	}
+
+	if !app.WatchConfig {
+		return app.loader.Load(app.Config)
+	}
+
+	app.watcher, err = zconfig.WatchLoader(app.loader, app.Config, checkConfig)
+	if err != nil {
+		return err
+	}
+
+	app.watcher.Subscribe(BaseReload)
+	for _, f := range app.subscribers {
+		app.watcher.Subscribe(f)
+	}
 
 	return nil
 }
 
+// checkConfig 先校验再填充默认值，与 ICheck 在 IDefault 之前执行的顺序保持一致，依赖默认值的规则应放在 IDefault 之后自行检查
+func checkConfig(c any) error {
+	err := zvalid.Validate(c)
+	if err != nil {
+		return fmt.Errorf("validate config error [%v]", err)
+	}
+
+	icheck, ok := c.(ICheck)
+	if ok {
+		err := icheck.Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	idefault, ok := c.(IDefault)
+	if ok {
+		idefault.Default()
+	}
+
+	return nil
+}
+
+// PrintConfig 输出生效的配置项及其来源
+func (app *App) PrintConfig(w io.Writer) {
+	if app.loader != nil {
+		app.loader.Print(w)
+	}
+}
+
+// GetConfig 获取当前生效的配置，开启 WatchConfig 时为最近一次成功加载的配置
+func (app *App) GetConfig() any {
+	if app.watcher != nil {
+		return app.watcher.Load()
+	}
+	return app.Config
+}
+
+// OnConfigChange 订阅配置变化，仅在开启 WatchConfig 时生效
+func (app *App) OnConfigChange(f func(old any, new any)) {
+	if app.watcher != nil {
+		app.watcher.Subscribe(f)
+		return
+	}
+	app.subscribers = append(app.subscribers, f)
+}
+
 func (app *App) CallInitFuncs() (err error) {
 	if app.hasCallInitFuncs {
 		return nil
@@ -100,10 +204,42 @@ func (app *App) CallInitFuncs() (err error) {
 	err = initFuncs.Init()
 	if err != nil {
 		zlog.Errorf("Init funcs failed, error: %v", err)
+		app.release()
 		return err
 	}
 	zlog.Info("Init funcs succeed.")
 
+	err = app.initModules()
+	if err != nil {
+		app.release()
+		return err
+	}
+
+	return nil
+}
+
+func (app *App) initModules() (err error) {
+	modules := app.Modules
+	if app.ModulesCb != nil {
+		modules = app.ModulesCb()
+	}
+
+	app.modules, err = modules.Sort()
+	if err != nil {
+		zlog.Errorf("Sort modules failed, error: %v", err)
+		return err
+	}
+
+	for _, module := range app.modules {
+		err = module.init()
+		if err != nil {
+			zlog.Errorf("Init module failed, name: %s, error: %v", module.Name, err)
+			return fmt.Errorf("init module [%s] error [%v]", module.Name, err)
+		}
+		app.inited = append(app.inited, module)
+		zlog.Infof("Init module succeed, name: %s.", module.Name)
+	}
+
 	return nil
 }
 
@@ -122,7 +258,9 @@ func (app *App) CallBootFuncs() (error, context.CancelFunc) {
 		return err, nil
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	zhealth.SetReady(false)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), appKey{}, true))
 
 	bootFuncs := app.BootFuncs
 	if app.BootFuncsCb != nil {
@@ -134,11 +272,104 @@ func (app *App) CallBootFuncs() (error, context.CancelFunc) {
 	if err != nil {
 		zlog.Errorf("Boot funcs failed, error: %v", err)
 		cancel()
+		app.release()
 		return err, nil
 	}
 	zlog.Info("Boot funcs successfully.")
 
-	return nil, cancel
+	err = app.startModules(ctx)
+	if err != nil {
+		cancel()
+		app.release()
+		return err, nil
+	}
+
+	zhealth.SetReady(true)
+
+	return nil, func() {
+		app.shutdown(cancel)
+	}
+}
+
+// shutdown 取消 context，等待登记的任务结束或超时，最后停止模块
+func (app *App) shutdown(cancel context.CancelFunc) {
+	zhealth.SetReady(false)
+
+	cancel()
+
+	stragglers := zshutdown.Wait(app.waitTime())
+	if len(stragglers) > 0 {
+		zlog.Warnf("Wait tasks timeout, unfinished: %s.", strings.Join(stragglers, ", "))
+	}
+
+	app.release()
+
+	zlog.Finally(zlog.DefaultFlushTimeout)
+}
+
+// release 停止已初始化的模块，并关闭 BaseInit 初始化的组件
+func (app *App) release() {
+	app.stopModules()
+	finallyInited()
+}
+
+func (app *App) waitTime() time.Duration {
+	iwaittime, ok := app.Config.(IGetWaitTime)
+	if ok && iwaittime.GetWaitTime() > 0 {
+		return iwaittime.GetWaitTime()
+	}
+	return DefaultWaitTime
+}
+
+func (app *App) startModules(ctx context.Context) error {
+	for _, module := range app.modules {
+		err := module.start(ctx)
+		if err != nil {
+			zlog.Errorf("Start module failed, name: %s, error: %v", module.Name, err)
+			return fmt.Errorf("start module [%s] error [%v]", module.Name, err)
+		}
+		app.started = append(app.started, module)
+		if module.Health != nil {
+			zhealth.Register(zhealth.Probe{
+				Name:    "module." + module.Name,
+				Timeout: module.timeout(),
+				Check:   module.Health,
+			})
+		}
+		zlog.Infof("Start module succeed, name: %s.", module.Name)
+	}
+	return nil
+}
+
+// stopModules 按初始化的相反顺序停止模块，包括已初始化但未启动的模块，并等待每个模块停止完成或超时
+func (app *App) stopModules() {
+	for _, module := range app.started {
+		zhealth.Unregister("module." + module.Name)
+	}
+	app.started = nil
+
+	for i := len(app.inited) - 1; i >= 0; i-- {
+		module := app.inited[i]
+		err := module.stop()
+		if err != nil {
+			zlog.Warnf("Stop module failed, name: %s, error: %v.", module.Name, err)
+		} else {
+			zlog.Infof("Stop module succeed, name: %s.", module.Name)
+		}
+	}
+	app.inited = nil
+}
+
+// CheckModules 检查已启动模块的健康状态，返回不健康的模块及其错误
+func (app *App) CheckModules(ctx context.Context) map[string]error {
+	result := make(map[string]error)
+	for _, module := range app.started {
+		err := module.health(ctx)
+		if err != nil {
+			result[module.Name] = err
+		}
+	}
+	return result
 }
 
 // Run app
@@ -153,25 +384,32 @@ func (app *App) Run() (err error) {
 func (app *App) CallBootFuncsBlocked() (err error) {
 	err, cancel := app.CallBootFuncs()
 	if err != nil {
+		zlog.Finally(zlog.DefaultFlushTimeout)
 		return err
 	}
 
 	zlog.Info("App run successfully.")
 
-	exitCh := make(chan os.Signal)
+	zlog.LevelOnSignal()
+
+	exitCh := make(chan os.Signal, 2)
 	signal.Notify(exitCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-exitCh
 
 	zlog.Info("App prepare exit.")
 
-	cancel()
+	// 再次收到退出信号时立即退出
+	go func() {
+		<-exitCh
+		zlog.Warnf("App force exit, unfinished: %s.", strings.Join(zshutdown.Running(), ", "))
+		zlog.Finally(time.Second)
+		os.Exit(1)
+	}()
 
-	iwaittime, ok := app.Config.(IGetWaitTime)
-	if ok {
-		time.Sleep(iwaittime.GetWaitTime())
-	}
+	cancel()
 
 	zlog.Info("App exit.")
+	zlog.Finally(zlog.DefaultFlushTimeout)
 
 	return nil
 }
@@ -13,6 +13,16 @@ var _rootCommand *cobra.Command
 func ExecuteCommand(app *App, commands ...func(app *App) *cobra.Command) {
 	initRootCommand(app)
 
+	builtins := []func(app *App) *cobra.Command{
+		ConfigCommand,
+		VersionCommand,
+		HealthCommand,
+		SecretCommand,
+	}
+	for _, command := range builtins {
+		_rootCommand.AddCommand(command(app))
+	}
+
 	for _, command := range commands {
 		_rootCommand.AddCommand(command(app))
 	}
@@ -24,9 +34,15 @@ func ExecuteCommand(app *App, commands ...func(app *App) *cobra.Command) {
 	}
 }
 
-func initRootCommand(app *App) {
-	config, logdir := "", ""
+type rootFlags struct {
+	config string
+	logdir string
+	sets   []string
+}
 
+var _rootFlags rootFlags
+
+func initRootCommand(app *App) {
 	_rootCommand = &cobra.Command{
 		Version: app.Version,
 		Use:     app.Name,
@@ -34,12 +50,7 @@ func initRootCommand(app *App) {
 			fmt.Printf("Use %s.bin -h or --help for help.\n", app.Name)
 		},
 		PersistentPreRun: func(command *cobra.Command, args []string) {
-			if strings.TrimSpace(config) != "" {
-				app.ConfigPath = config
-			}
-			if strings.TrimSpace(logdir) != "" {
-				app.LogdirPath = logdir
-			}
+			applyRootFlags(app, command)
 			err := app.InitConfig()
 			if err != nil {
 				fmt.Printf("Init app failed, error: %v\n", err)
@@ -48,6 +59,33 @@ func initRootCommand(app *App) {
 		},
 	}
 
-	_rootCommand.PersistentFlags().StringVarP(&config, "config", "c", "", "config path")
-	_rootCommand.PersistentFlags().StringVarP(&logdir, "logdir", "d", "", "logdir path")
+	_rootCommand.PersistentFlags().StringVarP(&_rootFlags.config, "config", "c", "", "config path")
+	_rootCommand.PersistentFlags().StringVarP(&_rootFlags.logdir, "logdir", "d", "", "logdir path")
+	_rootCommand.PersistentFlags().StringArrayVar(&_rootFlags.sets, "set", nil, "override config item, e.g. --set log.level=info")
+}
+
+// applyRootFlags 将根命令的参数应用到 app
+func applyRootFlags(app *App, command *cobra.Command) {
+	if strings.TrimSpace(_rootFlags.config) != "" {
+		app.ConfigPath = _rootFlags.config
+	}
+	if strings.TrimSpace(_rootFlags.logdir) != "" {
+		app.LogdirPath = _rootFlags.logdir
+	}
+	app.flags = command.Flags()
+	app.overrides = _rootFlags.sets
+}
+
+// loadConfigOnly 仅加载并检查配置，不初始化日志等
+func loadConfigOnly(app *App, command *cobra.Command) {
+	applyRootFlags(app, command)
+
+	err := app.loadConfig()
+	if err == nil {
+		err = checkConfig(app.Config)
+	}
+	if err != nil {
+		fmt.Printf("Load config failed, error: %v\n", err)
+		os.Exit(1)
+	}
 }
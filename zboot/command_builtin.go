@@ -0,0 +1,169 @@
+package zboot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yyliziqiu/zlib/zutil"
+	"github.com/yyliziqiu/zlib/zweb"
+)
+
+// 构建信息，通过 -ldflags "-X github.com/yyliziqiu/zlib/zboot.BuildCommit=xxx" 注入
+var (
+	BuildVersion string
+	BuildCommit  string
+	BuildTime    string
+)
+
+// ConfigCommand config check 检查配置，config show 输出生效的配置
+func ConfigCommand(app *App) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "Check or show config",
+		PersistentPreRun: func(command *cobra.Command, args []string) {
+			loadConfigOnly(app, command)
+		},
+	}
+
+	command.AddCommand(&cobra.Command{
+		Use:   "check",
+		Short: "Check config, exit with non-zero code if invalid",
+		Run: func(command *cobra.Command, args []string) {
+			// 配置已在 PersistentPreRun 中检查，失败时不会执行到这里
+			fmt.Printf("Config %s is valid.\n", app.ConfigPath)
+		},
+	})
+
+	command.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Show effective config with sources, secrets are redacted",
+		Run: func(command *cobra.Command, args []string) {
+			app.PrintConfig(os.Stdout)
+		},
+	})
+
+	return command
+}
+
+// VersionCommand 输出版本及构建信息
+func VersionCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:              "version",
+		Short:            "Show version and build info",
+		PersistentPreRun: func(command *cobra.Command, args []string) {},
+		Run: func(command *cobra.Command, args []string) {
+			printVersion(app, os.Stdout)
+		},
+	}
+}
+
+func printVersion(app *App, w io.Writer) {
+	version := app.Version
+	if BuildVersion != "" {
+		version = BuildVersion
+	}
+
+	commit, buildTime, modified := BuildCommit, BuildTime, ""
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = setting.Value
+				}
+			case "vcs.time":
+				if buildTime == "" {
+					buildTime = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Name:     %s\n", app.Name)
+	_, _ = fmt.Fprintf(w, "Version:  %s\n", version)
+	_, _ = fmt.Fprintf(w, "Commit:   %s\n", commit)
+	if modified == "true" {
+		_, _ = fmt.Fprintf(w, "Modified: %s\n", modified)
+	}
+	_, _ = fmt.Fprintf(w, "Built:    %s\n", buildTime)
+	_, _ = fmt.Fprintf(w, "Go:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if ok {
+		_, _ = fmt.Fprintf(w, "Module:   %s %s\n", info.Main.Path, info.Main.Version)
+	}
+}
+
+// HealthCommand 请求运行中实例的健康检查接口，不健康时以非零状态码退出
+func HealthCommand(app *App) *cobra.Command {
+	var (
+		url     string
+		timeout time.Duration
+	)
+
+	command := &cobra.Command{
+		Use:   "health",
+		Short: "Check health of the running instance",
+		PersistentPreRun: func(command *cobra.Command, args []string) {
+			loadConfigOnly(app, command)
+		},
+		Run: func(command *cobra.Command, args []string) {
+			if url == "" {
+				url = healthURL(app.Config)
+			}
+
+			cli := &http.Client{Timeout: timeout}
+			res, err := cli.Get(url)
+			if err != nil {
+				fmt.Printf("Request %s failed, error: %v\n", url, err)
+				os.Exit(1)
+			}
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			fmt.Println(string(body))
+
+			if res.StatusCode != http.StatusOK {
+				fmt.Printf("Unhealthy, status: %d\n", res.StatusCode)
+				os.Exit(1)
+			}
+		},
+	}
+
+	command.Flags().StringVar(&url, "url", "", "health check url, default is derived from Web.Addr")
+	command.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "request timeout")
+
+	return command
+}
+
+// DefaultHealthPath 默认的健康检查路径
+const DefaultHealthPath = "/healthz"
+
+func healthURL(config any) string {
+	web := zweb.Config{}
+	val, ok := zutil.StructFieldValue(config, "Web")
+	if ok {
+		if c, ok2 := val.(zweb.Config); ok2 {
+			web = c
+		}
+	}
+	web = web.Default()
+
+	addr := web.Addr
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	} else if strings.HasPrefix(addr, "0.0.0.0:") {
+		addr = "127.0.0.1" + strings.TrimPrefix(addr, "0.0.0.0")
+	}
+
+	return "http://" + addr + DefaultHealthPath
+}
@@ -0,0 +1,67 @@
+package zboot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yyliziqiu/zlib/zconfig"
+)
+
+// SecretCommand 加密、解密配置项。密钥取自 --key 参数，
+// 未指定时从环境变量 ZCONFIG_SECRET_KEY 或 ZCONFIG_SECRET_KEY_FILE 指向的文件中读取。
+func SecretCommand(app *App) *cobra.Command {
+	key := ""
+
+	command := &cobra.Command{
+		Use:   "secret",
+		Short: "Encrypt or decrypt config values",
+		// 不需要加载配置文件
+		PersistentPreRun: func(command *cobra.Command, args []string) {},
+	}
+	command.PersistentFlags().StringVarP(&key, "key", "k", "", "secret key")
+
+	command.AddCommand(&cobra.Command{
+		Use:   "encrypt <value>",
+		Short: "Encrypt a config value",
+		Args:  cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			value, err := zconfig.Encrypt(secretKey(key), args[0])
+			if err != nil {
+				fmt.Printf("Encrypt failed, error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(value)
+		},
+	})
+
+	command.AddCommand(&cobra.Command{
+		Use:   "decrypt <value>",
+		Short: "Decrypt a config value",
+		Args:  cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			value, err := zconfig.Decrypt(secretKey(key), args[0])
+			if err != nil {
+				fmt.Printf("Decrypt failed, error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(value)
+		},
+	})
+
+	return command
+}
+
+func secretKey(key string) string {
+	if strings.TrimSpace(key) != "" {
+		return key
+	}
+	key, err := zconfig.SecretKey()
+	if err != nil {
+		fmt.Printf("Get secret key failed, error: %v\n", err)
+		os.Exit(1)
+	}
+	return key
+}
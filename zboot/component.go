@@ -0,0 +1,123 @@
+package zboot
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/yyliziqiu/zlib/zdb"
+	"github.com/yyliziqiu/zlib/zelastic"
+	"github.com/yyliziqiu/zlib/zkafka"
+	"github.com/yyliziqiu/zlib/zredis"
+	"github.com/yyliziqiu/zlib/ztrace"
+	"github.com/yyliziqiu/zlib/zutil"
+)
+
+// Component 可插拔的组件，BaseInit、BaseBoot 和 BaseModules 根据配置中 Field 字段的值初始化和关闭组件
+type Component struct {
+	Name    string              // must, 组件名称，同时作为模块名称
+	Field   string              // must, 配置结构体中的字段名称，如 DB
+	Depends []string            // optional, 依赖的组件名称
+	Init    func(val any) error // must, val 为配置字段的值
+	Finally func()              // optional
+	// optional, 判断配置字段的值是否属于该组件，返回 false 时跳过该组件，用于忽略应用自定义的同名字段
+	Match func(val any) bool
+}
+
+var (
+	_componentsMu sync.Mutex
+	_components   []Component
+)
+
+func init() {
+	RegisterComponent(Component{
+		Name:  "trace",
+		Field: "Trace",
+		Init: func(val any) error {
+			config, ok := val.(ztrace.Config)
+			if !ok {
+				return fmt.Errorf("config field [Trace] must be %T", config)
+			}
+			return ztrace.Init(config)
+		},
+		Finally: ztrace.Finally,
+		Match: func(val any) bool {
+			_, ok := val.(ztrace.Config)
+			return ok
+		},
+	})
+	RegisterComponent(NewComponent("db", "DB", zdb.Init, zdb.Finally))
+	RegisterComponent(NewComponent("redis", "Redis", zredis.Init, zredis.Finally))
+	RegisterComponent(NewComponent("kafka", "Kafka", zkafka.Init, zkafka.Finally))
+	RegisterComponent(NewComponent("elastic", "Elastic", zelastic.Init, zelastic.Finally))
+}
+
+// NewComponent 使用形如 zdb.Init(configs ...zdb.Config) error 的初始化函数创建组件，
+// 配置字段的类型必须为 []T
+func NewComponent[T any](name string, field string, init func(configs ...T) error, finally func()) Component {
+	return Component{
+		Name:  name,
+		Field: field,
+		Init: func(val any) error {
+			configs, ok := val.([]T)
+			if !ok {
+				return fmt.Errorf("config field [%s] must be %T", field, configs)
+			}
+			return init(configs...)
+		},
+		Finally: finally,
+		Match: func(val any) bool {
+			_, ok := val.([]T)
+			return ok
+		},
+	}
+}
+
+// RegisterComponent 注册组件，同名组件会被替换
+func RegisterComponent(component Component) {
+	_componentsMu.Lock()
+	defer _componentsMu.Unlock()
+
+	for i, c := range _components {
+		if c.Name == component.Name {
+			_components[i] = component
+			return
+		}
+	}
+	_components = append(_components, component)
+}
+
+// Components 返回已注册的组件，按注册顺序排列
+func Components() []Component {
+	_componentsMu.Lock()
+	defer _componentsMu.Unlock()
+
+	return append([]Component(nil), _components...)
+}
+
+// componentConfig 获取组件的配置，配置字段不存在、为空或类型不属于该组件时返回 false
+func componentConfig(config any, component Component) (any, bool) {
+	val, ok := zutil.StructFieldValue(config, component.Field)
+	if !ok || val == nil {
+		return nil, false
+	}
+
+	// 应用自定义的同名字段不属于该组件，跳过
+	if component.Match != nil && !component.Match(val) {
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		if rv.Len() == 0 {
+			return nil, false
+		}
+	default:
+		if rv.IsZero() {
+			return nil, false
+		}
+	}
+
+	return val, true
+}
@@ -29,13 +29,13 @@ type IGetLog interface {
 	GetLog() zlog.Config
 }
 
-// IGetWaitTime 获取应用退出时等待时长配置
+// IGetWaitTime 获取应用退出时等待任务结束的最长时长配置，未配置时为 DefaultWaitTime
 type IGetWaitTime interface {
 	GetWaitTime() time.Duration
 }
 
 type Config struct {
-	Env      string
+	Env      string `valid:"oneof=dev test prod"`
 	AppId    string
 	InsId    string
 	BasePath string
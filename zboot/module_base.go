@@ -3,78 +3,97 @@ package zboot
 import (
 	"context"
 	"fmt"
+	"sync"
 
-	"github.com/yyliziqiu/zlib/zdb"
-	"github.com/yyliziqiu/zlib/zelastic"
-	"github.com/yyliziqiu/zlib/zkafka"
 	"github.com/yyliziqiu/zlib/zlog"
-	"github.com/yyliziqiu/zlib/zredis"
-	"github.com/yyliziqiu/zlib/zutil"
 )
 
+var (
+	_initedMu sync.Mutex
+	_inited   []Component
+)
+
+// BaseInit 初始化配置中存在的已注册组件
 func BaseInit(config any) InitFunc {
 	return func() (err error) {
-		val, ok := zutil.StructFieldValue(config, "DB")
-		if ok {
-			c, ok2 := val.([]zdb.Config)
-			if ok2 && len(c) > 0 {
-				zlog.Info("Init DB.")
-				err = zdb.Init(c...)
-				if err != nil {
-					return fmt.Errorf("init DB error [%v]", err)
-				}
+		for _, component := range Components() {
+			val, ok := componentConfig(config, component)
+			if !ok {
+				continue
 			}
-		}
 
-		val, ok = zutil.StructFieldValue(config, "Redis")
-		if ok {
-			c, ok2 := val.([]zredis.Config)
-			if ok2 && len(c) > 0 {
-				zlog.Info("Init redis.")
-				err = zredis.Init(c...)
-				if err != nil {
-					return fmt.Errorf("init redis error [%v]", err)
-				}
+			zlog.Infof("Init %s.", component.Name)
+			err = component.Init(val)
+			if err != nil {
+				return fmt.Errorf("init %s error [%v]", component.Name, err)
 			}
-		}
 
-		val, ok = zutil.StructFieldValue(config, "Kafka")
-		if ok {
-			c, ok2 := val.([]zkafka.Config)
-			if ok2 && len(c) > 0 {
-				zlog.Info("Init kafka.")
-				err = zkafka.Init(c...)
-				if err != nil {
-					return fmt.Errorf("init kafka error [%v]", err)
-				}
-			}
+			_initedMu.Lock()
+			_inited = append(_inited, component)
+			_initedMu.Unlock()
 		}
-
-		val, ok = zutil.StructFieldValue(config, "Elastic")
-		if ok {
-			c, ok2 := val.([]zelastic.Config)
-			if ok2 && len(c) > 0 {
-				zlog.Info("Init elastic.")
-				err = zelastic.Init(c...)
-				if err != nil {
-					return fmt.Errorf("init elastic error [%v]", err)
-				}
-			}
-		}
-
 		return nil
 	}
 }
 
+// appKey 标记 ctx 由 App 创建，App 会在停止模块后同步关闭 BaseInit 初始化的组件
+type appKey struct{}
+
+// BaseBoot 在 ctx 取消后按初始化的相反顺序关闭 BaseInit 初始化的组件，
+// 通过 App 运行时由 App 在停止模块后同步关闭，BaseBoot 不做处理
 func BaseBoot() BootFunc {
 	return func(ctx context.Context) error {
+		if ctx.Value(appKey{}) != nil {
+			return nil
+		}
 		go func() {
 			<-ctx.Done()
-			zdb.Finally()
-			zredis.Finally()
-			zkafka.Finally()
-			zelastic.Finally()
+			finallyInited()
 		}()
 		return nil
 	}
 }
+
+// finallyInited 按初始化的相反顺序同步关闭 BaseInit 初始化的组件
+func finallyInited() {
+	_initedMu.Lock()
+	inited := _inited
+	_inited = nil
+	_initedMu.Unlock()
+
+	for i := len(inited) - 1; i >= 0; i-- {
+		if inited[i].Finally != nil {
+			zlog.Infof("Finally %s.", inited[i].Name)
+			inited[i].Finally()
+		}
+	}
+}
+
+// BaseModules 以模块的形式初始化配置中存在的已注册组件，
+// 与 BaseInit 不同，组件按依赖关系初始化，退出时按依赖的相反顺序同步关闭。
+func BaseModules(config any) Modules {
+	var modules Modules
+
+	for _, component := range Components() {
+		val, ok := componentConfig(config, component)
+		if !ok {
+			continue
+		}
+
+		c := component
+		module := Module{
+			Name:    c.Name,
+			Depends: c.Depends,
+			Init:    func(context.Context) error { return c.Init(val) },
+		}
+		if c.Finally != nil {
+			module.Stop = func(context.Context) error {
+				c.Finally()
+				return nil
+			}
+		}
+		modules = append(modules, module)
+	}
+
+	return modules
+}
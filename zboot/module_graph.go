@@ -0,0 +1,174 @@
+package zboot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+// DefaultModuleTimeout 模块各生命周期钩子的默认超时时长
+const DefaultModuleTimeout = 30 * time.Second
+
+// Module 带依赖声明和生命周期钩子的模块。
+// 模块按依赖关系拓扑排序后依次 Init、Start，退出时按相反顺序 Stop。
+type Module struct {
+	Name    string        // must
+	Depends []string      // optional, 依赖的模块名称
+	Timeout time.Duration // optional, 每个钩子的超时时长
+
+	Init   func(ctx context.Context) error // optional, ctx 在超时后取消
+	Start  func(ctx context.Context) error // optional, ctx 在 app 退出时取消
+	Stop   func(ctx context.Context) error // optional, ctx 在超时后取消，初始化后未启动的模块也会调用
+	Health func(ctx context.Context) error // optional
+}
+
+func (m Module) timeout() time.Duration {
+	if m.Timeout <= 0 {
+		return DefaultModuleTimeout
+	}
+	return m.Timeout
+}
+
+func (m Module) init() error {
+	if m.Init == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout())
+	defer cancel()
+	return m.callTimeout("init", func() error {
+		return m.Init(ctx)
+	})
+}
+
+func (m Module) start(ctx context.Context) error {
+	if m.Start == nil {
+		return nil
+	}
+	return m.callTimeout("start", func() error {
+		return m.Start(ctx)
+	})
+}
+
+func (m Module) stop() error {
+	if m.Stop == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout())
+	defer cancel()
+	return m.callTimeout("stop", func() error {
+		return m.Stop(ctx)
+	})
+}
+
+func (m Module) health(ctx context.Context) error {
+	if m.Health == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout())
+	defer cancel()
+	return m.callTimeout("health", func() error {
+		return m.Health(ctx)
+	})
+}
+
+// callTimeout 执行 f，超过超时时长未返回时返回超时错误。
+// 超时后 f 仍在后台运行，钩子需要在 ctx 取消后尽快返回。
+func (m Module) callTimeout(hook string, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	timeout := m.timeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		zlog.Warnf("Module %s timeout, left running, name: %s, timeout: %s.", hook, m.Name, timeout)
+		go func() {
+			err := <-done
+			zlog.Warnf("Module %s returned after timeout, name: %s, error: %v.", hook, m.Name, err)
+		}()
+		return fmt.Errorf("timeout after %s", timeout)
+	}
+}
+
+type Modules []Module
+
+const (
+	moduleUnvisited = iota
+	moduleVisiting
+	moduleVisited
+)
+
+// Sort 按依赖关系对模块进行拓扑排序，被依赖的模块排在前面，
+// 无依赖关系的模块保持声明顺序。存在循环依赖时返回包含依赖路径的错误。
+func (list Modules) Sort() (Modules, error) {
+	index := make(map[string]int, len(list))
+	for i, m := range list {
+		if m.Name == "" {
+			return nil, fmt.Errorf("module name is empty [%d]", i)
+		}
+		if _, ok := index[m.Name]; ok {
+			return nil, fmt.Errorf("module [%s] is duplicated", m.Name)
+		}
+		index[m.Name] = i
+	}
+
+	var (
+		states = make([]int, len(list))
+		sorted = make(Modules, 0, len(list))
+		path   = make([]string, 0, len(list))
+		visit  func(i int) error
+	)
+
+	visit = func(i int) error {
+		switch states[i] {
+		case moduleVisited:
+			return nil
+		case moduleVisiting:
+			cycle := []string{list[i].Name}
+			for j := len(path) - 1; j >= 0; j-- {
+				cycle = append([]string{path[j]}, cycle...)
+				if path[j] == list[i].Name {
+					break
+				}
+			}
+			return fmt.Errorf("module dependency cycle [%s]", strings.Join(cycle, " -> "))
+		}
+
+		states[i] = moduleVisiting
+		path = append(path, list[i].Name)
+		for _, dep := range list[i].Depends {
+			j, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("module [%s] depends on unknown module [%s]", list[i].Name, dep)
+			}
+			err := visit(j)
+			if err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = moduleVisited
+
+		sorted = append(sorted, list[i])
+
+		return nil
+	}
+
+	for i := range list {
+		err := visit(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,116 @@
+package zboot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+func TestModulesSort(t *testing.T) {
+	modules := Modules{
+		{Name: "web", Depends: []string{"db", "redis"}},
+		{Name: "db"},
+		{Name: "redis", Depends: []string{"db"}},
+		{Name: "task"},
+	}
+
+	sorted, err := modules.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, m := range sorted {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "db,redis,web,task" {
+		t.Fatalf("unexpected order %v", names)
+	}
+}
+
+func TestModulesSortCycle(t *testing.T) {
+	modules := Modules{
+		{Name: "a", Depends: []string{"b"}},
+		{Name: "b", Depends: []string{"c"}},
+		{Name: "c", Depends: []string{"a"}},
+	}
+
+	_, err := modules.Sort()
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestAppStopInitedModules(t *testing.T) {
+	err := zlog.Init(zlog.Config{Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stopped []string
+	module := func(name string, initErr error) Module {
+		return Module{
+			Name: name,
+			Init: func(context.Context) error { return initErr },
+			Stop: func(context.Context) error {
+				stopped = append(stopped, name)
+				return nil
+			},
+		}
+	}
+
+	app := &App{Modules: Modules{
+		module("db", nil),
+		module("redis", nil),
+		module("kafka", errors.New("broken")),
+	}}
+	err = app.CallInitFuncs()
+	if err == nil {
+		t.Fatal("expected init error")
+	}
+	if strings.Join(stopped, ",") != "redis,db" {
+		t.Fatalf("unexpected stopped %v", stopped)
+	}
+}
+
+func TestComponentTypeMismatch(t *testing.T) {
+	type redisConfig struct {
+		Addr string
+	}
+	config := struct {
+		Redis redisConfig
+		Trace bool
+	}{Redis: redisConfig{Addr: "127.0.0.1:6379"}, Trace: true}
+
+	err := BaseInit(&config)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules := BaseModules(&config); len(modules) != 0 {
+		t.Fatalf("unexpected modules %v", modules)
+	}
+}
+
+func TestBaseBootFinally(t *testing.T) {
+	closed := make(chan struct{})
+	_initedMu.Lock()
+	_inited = append(_inited, Component{Name: "test", Finally: func() { close(closed) }})
+	_initedMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := BaseBoot()(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("BaseBoot did not close components")
+	}
+}
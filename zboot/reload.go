@@ -0,0 +1,37 @@
+package zboot
+
+import (
+	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/ztask"
+	"github.com/yyliziqiu/zlib/zutil"
+	"github.com/yyliziqiu/zlib/zweb"
+)
+
+// BaseReload 将新配置中的日志等级、定时任务周期和跨域设置应用到运行中的服务
+func BaseReload(old any, new any) {
+	ilog, ok := new.(IGetLog)
+	if ok {
+		if level := ilog.GetLog().Level; level != "" {
+			zlog.SetLevel(level)
+		}
+	}
+
+	val, ok := zutil.StructFieldValue(new, "CronTask")
+	if ok {
+		c, ok2 := val.([]ztask.CronTask)
+		if ok2 && len(c) > 0 {
+			err := ztask.UpdateCronTasks(c)
+			if err != nil {
+				zlog.Errorf("Reload cron tasks failed, error: %v.", err)
+			}
+		}
+	}
+
+	val, ok = zutil.StructFieldValue(new, "Web")
+	if ok {
+		c, ok2 := val.(zweb.Config)
+		if ok2 {
+			zweb.SetCrosHeaders(c.Cros)
+		}
+	}
+}
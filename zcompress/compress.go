@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"compress/zlib"
 	"io"
+	"os"
 )
 
 func Gzip(data []byte) ([]byte, error) {
@@ -78,3 +79,34 @@ func UnZlib(data []byte) ([]byte, error) {
 
 	return result, nil
 }
+
+// GzipFile 将 src 压缩后写入 dst，适用于较大的文件
+func GzipFile(src string, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err2 := out.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	wtr := gzip.NewWriter(out)
+
+	_, err = io.Copy(wtr, in)
+	if err != nil {
+		return err
+	}
+
+	return wtr.Close()
+}
@@ -0,0 +1,365 @@
+package zconfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// IncludeKey 配置文件中用于引入其他配置文件的键，路径相对于当前配置文件所在目录
+const IncludeKey = "include"
+
+// Loader 按以下顺序分层加载配置，后加载的覆盖先加载的：
+//  1. 基础配置文件 Path，及其 include 的文件（被引入的文件先加载）
+//  2. 环境覆盖文件，如 Path 为 conf/app.yaml，Env 为 dev 时加载 conf/app.dev.yaml，文件不存在时忽略
+//  3. 以 EnvPrefix 为前缀的环境变量，如 APP_LOG_LEVEL 对应 Log.Level
+//  4. Flags 中被修改过的命令行参数，如 --log-level 对应 Log.Level，--app-id 对应 AppId，以及 Overrides 中的 key=value
+//
+// 加载完成后解析配置值中的 ${file:...}、${env:...} 和 enc: 密钥引用，参考 ResolveSecret。
+type Loader struct {
+	Path      string         // must
+	Env       string         // optional, 为空时依次从环境变量 <EnvPrefix>_ENV 和配置项 env 获取
+	EnvPrefix string         // optional, 为空时不读取环境变量
+	Flags     *pflag.FlagSet // optional
+	Overrides []string       // optional, 形如 log.level=info
+
+	mu      sync.RWMutex
+	v       *viper.Viper
+	files   []string
+	sources map[string]string
+}
+
+// Load 加载配置并解析到 c
+func (l *Loader) Load(c any) error {
+	return l.LoadCheck(c, nil)
+}
+
+// LoadCheck 加载配置并解析到 c，check 不为 nil 时校验配置，加载或校验失败时 Files、Source 和 Dump 仍返回之前的配置
+func (l *Loader) LoadCheck(c any, check func(c any) error) error {
+	n := &Loader{
+		Path:      l.Path,
+		Env:       l.Env,
+		EnvPrefix: l.EnvPrefix,
+		Flags:     l.Flags,
+		Overrides: l.Overrides,
+		v:         viper.New(),
+		sources:   make(map[string]string, 64),
+	}
+
+	err := n.load(c)
+	if err != nil {
+		return err
+	}
+
+	if check != nil {
+		err = check(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	l.v, l.files, l.sources = n.v, n.files, n.sources
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *Loader) load(c any) error {
+	err := l.mergeFile(l.Path, nil)
+	if err != nil {
+		return err
+	}
+
+	if env := l.env(); env != "" {
+		path := envFilePath(l.Path, env)
+		if _, err = os.Stat(path); err == nil {
+			err = l.mergeFile(path, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	keys := structKeys(reflect.TypeOf(c), "", "")
+	l.mergeEnvs(keys)
+	l.mergeFlags(keys)
+	err = l.mergeOverrides()
+	if err != nil {
+		return err
+	}
+
+	err = l.resolveSecrets()
+	if err != nil {
+		return err
+	}
+
+	err = l.v.Unmarshal(c)
+	if err != nil {
+		return fmt.Errorf("unmarshal config [%s] failed [%v]", l.Path, err)
+	}
+
+	return nil
+}
+
+func (l *Loader) mergeFile(path string, visiting []string) error {
+	abs, _ := filepath.Abs(path)
+	for _, p := range visiting {
+		if p == abs {
+			return fmt.Errorf("include config [%s] cyclically", path)
+		}
+	}
+	visiting = append(visiting, abs)
+
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	err := fv.ReadInConfig()
+	if err != nil {
+		return fmt.Errorf("read config [%s] failed [%v]", path, err)
+	}
+
+	for _, include := range fv.GetStringSlice(IncludeKey) {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		err = l.mergeFile(include, visiting)
+		if err != nil {
+			return err
+		}
+	}
+
+	settings := fv.AllSettings()
+	delete(settings, IncludeKey)
+
+	err = l.v.MergeConfigMap(settings)
+	if err != nil {
+		return fmt.Errorf("merge config [%s] failed [%v]", path, err)
+	}
+
+	for _, key := range fv.AllKeys() {
+		if key != IncludeKey {
+			l.sources[key] = path
+		}
+	}
+	l.files = append(l.files, path)
+
+	return nil
+}
+
+func (l *Loader) env() string {
+	if l.Env != "" {
+		return l.Env
+	}
+	if l.EnvPrefix != "" {
+		if env := os.Getenv(envName(l.EnvPrefix, "env")); env != "" {
+			return env
+		}
+	}
+	return l.v.GetString("env")
+}
+
+func (l *Loader) mergeEnvs(keys []structKey) {
+	if l.EnvPrefix == "" {
+		return
+	}
+	for _, sk := range keys {
+		key := sk.Key
+		name := envName(l.EnvPrefix, key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		l.v.Set(key, value)
+		l.sources[key] = "env:" + name
+	}
+}
+
+// mergeFlags 按配置结构体的字段名匹配命令行参数，如 --app-id 对应 AppId，--log-max-size 对应 Log.MaxSize，
+// 也兼容将配置项路径中的 . 替换为 - 的写法，如 --appid、--log-maxsize
+func (l *Loader) mergeFlags(keys []structKey) {
+	if l.Flags == nil {
+		return
+	}
+	known := make(map[string]string, len(keys)*2)
+	for _, sk := range keys {
+		known[strings.ReplaceAll(sk.Key, ".", "-")] = sk.Key
+		known[sk.Flag] = sk.Key
+	}
+	l.Flags.Visit(func(flag *pflag.Flag) {
+		key, ok := known[strings.ToLower(flag.Name)]
+		if !ok {
+			return
+		}
+		l.v.Set(key, flag.Value.String())
+		l.sources[key] = "flag:--" + flag.Name
+	})
+}
+
+func (l *Loader) mergeOverrides() error {
+	for _, override := range l.Overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return fmt.Errorf("invalid config override [%s]", override)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		l.v.Set(key, value)
+		l.sources[key] = "flag:--set"
+	}
+	return nil
+}
+
+func (l *Loader) resolveSecrets() error {
+	for key, value := range l.v.AllSettings() {
+		resolved, changed, err := resolveSecrets(value)
+		if err != nil {
+			return fmt.Errorf("resolve config [%s] failed [%s: %v]", l.Path, key, err)
+		}
+		if changed {
+			l.v.Set(key, resolved)
+		}
+	}
+	return nil
+}
+
+// Files 返回已加载的配置文件，按加载顺序排列
+func (l *Loader) Files() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.files
+}
+
+// Source 返回配置项的来源
+func (l *Loader) Source(key string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.sources[strings.ToLower(key)]
+}
+
+// Entry 生效的配置项及其来源
+type Entry struct {
+	Key    string
+	Value  any
+	Source string
+}
+
+// Dump 返回所有生效的配置项，按键排序，其中的密钥已脱敏
+func (l *Loader) Dump() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.v == nil {
+		return nil
+	}
+
+	keys := l.v.AllKeys()
+	sort.Strings(keys)
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, Entry{
+			Key:    key,
+			Value:  redactValue(l.v.Get(key)),
+			Source: l.sources[key],
+		})
+	}
+
+	return entries
+}
+
+// Print 以 key = value  # source 的格式输出所有生效的配置项
+func (l *Loader) Print(w io.Writer) {
+	for _, entry := range l.Dump() {
+		_, _ = fmt.Fprintf(w, "%s = %v  # %s\n", entry.Key, entry.Value, entry.Source)
+	}
+}
+
+func envFilePath(path string, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+func envName(prefix string, key string) string {
+	return strings.ToUpper(prefix + "_" + strings.ReplaceAll(key, ".", "_"))
+}
+
+// structKey 结构体中可由单个值设置的字段，Key 为配置项路径，如 log.maxsize，Flag 为对应的命令行参数名，如 log-max-size
+type structKey struct {
+	Key  string
+	Flag string
+}
+
+// structKeys 返回结构体中可由单个值设置的字段，不展开切片和 map
+func structKeys(typ reflect.Type, prefix string, flagPrefix string) []structKey {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []structKey
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.ToLower(field.Name)
+		flag := kebabCase(field.Name)
+		if tag := field.Tag.Get("mapstructure"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			name = strings.ToLower(strings.Split(tag, ",")[0])
+			flag = strings.ReplaceAll(name, "_", "-")
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if flagPrefix != "" {
+			flag = flagPrefix + "-" + flag
+		}
+
+		ft := field.Type
+		switch {
+		case ft.Kind() == reflect.Struct && ft.PkgPath() != "time":
+			keys = append(keys, structKeys(ft, key, flag)...)
+		case ft.Kind() == reflect.Func || ft.Kind() == reflect.Chan || ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Interface:
+			continue
+		case ft.Kind() == reflect.Map || (ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct):
+			continue
+		default:
+			keys = append(keys, structKey{Key: key, Flag: flag})
+		}
+	}
+
+	return keys
+}
+
+// kebabCase 将字段名转换为命令行参数名，如 AppId 为 app-id，SASLUsername 为 sasl-username
+func kebabCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := rune(name[i-1])
+			next := i+1 < len(name) && unicode.IsLower(rune(name[i+1]))
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package zconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+type testConfig struct {
+	AppId string
+	Log   struct {
+		Level   string
+		MaxSize int
+	}
+}
+
+func TestLoaderFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	_ = os.WriteFile(path, []byte("appid: a\nlog:\n  level: info\n"), 0644)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("app-id", "", "")
+	flags.String("log-level", "", "")
+	flags.Int("log-max-size", 0, "")
+	_ = flags.Parse([]string{"--app-id=b", "--log-level=debug", "--log-max-size=10"})
+
+	var c testConfig
+	l := &Loader{Path: path, Flags: flags}
+	err := l.Load(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AppId != "b" || c.Log.Level != "debug" || c.Log.MaxSize != 10 {
+		t.Errorf("config: %+v", c)
+	}
+	if l.Source("appid") != "flag:--app-id" {
+		t.Errorf("source: %s", l.Source("appid"))
+	}
+
+	if kebabCase("SASLUsername") != "sasl-username" || kebabCase("DB") != "db" {
+		t.Errorf("kebab: %s, %s", kebabCase("SASLUsername"), kebabCase("DB"))
+	}
+}
+
+func TestWatchInclude(t *testing.T) {
+	err := zlog.Init(zlog.Config{Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	include := filepath.Join(dir, "log.yaml")
+	_ = os.WriteFile(path, []byte("appid: a\n"), 0644)
+	_ = os.WriteFile(include, []byte("log:\n  level: info\n"), 0644)
+
+	var c testConfig
+	w, err := Watch(path, &c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后监听新 include 的文件
+	_ = os.WriteFile(path, []byte("appid: a\ninclude: [log.yaml]\n"), 0644)
+	err = w.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 等待修改 app.yaml 触发的重新加载完成
+	time.Sleep(2 * watchDelay)
+	_ = os.WriteFile(include, []byte("log:\n  level: debug\n"), 0644)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if w.Load().(*testConfig).Log.Level == "debug" {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("include not reloaded: %+v", w.Load())
+}
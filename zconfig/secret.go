@@ -0,0 +1,285 @@
+package zconfig
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SecretKeyEnv 保存解密 enc: 配置项所用密钥的环境变量
+	SecretKeyEnv = "ZCONFIG_SECRET_KEY"
+
+	// SecretKeyFileEnv 保存密钥文件路径的环境变量，SecretKeyEnv 未设置时使用
+	SecretKeyFileEnv = "ZCONFIG_SECRET_KEY_FILE"
+
+	// EncryptedPrefix 加密配置项的前缀
+	EncryptedPrefix = "enc:"
+
+	// Redacted 输出配置或日志时用于替换密钥的字符串
+	Redacted = "******"
+
+	// 长度小于该值的密钥不参与脱敏，避免误替换
+	minRedactLength = 4
+)
+
+// 配置项中的 ${file:/run/secrets/x} 和 ${env:NAME} 引用
+var _secretRefRegexp = regexp.MustCompile(`\$\{(file|env):([^}]+)}`)
+
+var (
+	_secretsMu sync.RWMutex
+	_secrets   = make(map[string]struct{})
+)
+
+// SecretKey 从环境变量 SecretKeyEnv 或 SecretKeyFileEnv 指向的文件中读取密钥
+func SecretKey() (string, error) {
+	if key := os.Getenv(SecretKeyEnv); key != "" {
+		return key, nil
+	}
+	if path := os.Getenv(SecretKeyFileEnv); path != "" {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret key file [%s] failed [%v]", path, err)
+		}
+		return strings.TrimSpace(string(bs)), nil
+	}
+	return "", fmt.Errorf("secret key not found, set %s or %s", SecretKeyEnv, SecretKeyFileEnv)
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("secret key is empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt 使用 AES-GCM 加密 plaintext，返回带 enc: 前缀的配置值
+func Encrypt(key string, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的配置值
+func Decrypt(key string, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted value failed [%v]", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt value failed [%v]", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// ResolveSecret 解析配置值中的密钥引用，changed 表示是否包含引用
+func ResolveSecret(value string) (resolved string, changed bool, err error) {
+	if strings.HasPrefix(value, EncryptedPrefix) {
+		key, err := SecretKey()
+		if err != nil {
+			return "", false, err
+		}
+		resolved, err = Decrypt(key, value)
+		if err != nil {
+			return "", false, err
+		}
+		addSecret(resolved)
+		return resolved, true, nil
+	}
+
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+
+	resolved = _secretRefRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+
+		var (
+			match  = _secretRefRegexp.FindStringSubmatch(ref)
+			secret string
+		)
+		switch match[1] {
+		case "file":
+			var bs []byte
+			bs, err = os.ReadFile(match[2])
+			if err != nil {
+				err = fmt.Errorf("read secret file [%s] failed [%v]", match[2], err)
+				return ref
+			}
+			secret = strings.TrimRight(string(bs), "\r\n")
+		case "env":
+			var ok bool
+			secret, ok = os.LookupEnv(match[2])
+			if !ok {
+				err = fmt.Errorf("secret env [%s] is not set", match[2])
+				return ref
+			}
+		}
+
+		addSecret(secret)
+		changed = true
+
+		return secret
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return resolved, changed, nil
+}
+
+// resolveSecrets 递归解析 map 和切片中的密钥引用
+func resolveSecrets(value any) (any, bool, error) {
+	switch v := value.(type) {
+	case string:
+		return ResolveSecret(v)
+	case map[string]any:
+		changed := false
+		for key, item := range v {
+			resolved, ok, err := resolveSecrets(item)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %v", key, err)
+			}
+			if ok {
+				v[key] = resolved
+				changed = true
+			}
+		}
+		return v, changed, nil
+	case []any:
+		changed := false
+		for i, item := range v {
+			resolved, ok, err := resolveSecrets(item)
+			if err != nil {
+				return nil, false, fmt.Errorf("%d: %v", i, err)
+			}
+			if ok {
+				v[i] = resolved
+				changed = true
+			}
+		}
+		return v, changed, nil
+	default:
+		return value, false, nil
+	}
+}
+
+func addSecret(secret string) {
+	if len(secret) < minRedactLength {
+		return
+	}
+	_secretsMu.Lock()
+	_secrets[secret] = struct{}{}
+	_secretsMu.Unlock()
+}
+
+// Redact 将字符串中已解析的密钥替换为 Redacted
+func Redact(s string) string {
+	_secretsMu.RLock()
+	defer _secretsMu.RUnlock()
+
+	for secret := range _secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+
+	return s
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return Redact(v)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = redactValue(item)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, item := range v {
+			s[i] = redactValue(item)
+		}
+		return s
+	case error:
+		return redactString(v.Error(), value)
+	case fmt.Stringer:
+		return redactString(v.String(), value)
+	default:
+		return value
+	}
+}
+
+// redactString 返回 s 脱敏后的字符串，不包含密钥时返回原值
+func redactString(s string, value any) any {
+	r := Redact(s)
+	if r == s {
+		return value
+	}
+	return r
+}
+
+// RedactHook 对日志内容中已解析的密钥进行脱敏，error 和 fmt.Stringer 类型的字段按其字符串形式脱敏
+type RedactHook struct{}
+
+func (RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (RedactHook) Fire(entry *logrus.Entry) error {
+	_secretsMu.RLock()
+	empty := len(_secrets) == 0
+	_secretsMu.RUnlock()
+	if empty {
+		return nil
+	}
+
+	entry.Message = Redact(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = redactValue(value)
+	}
+
+	return nil
+}
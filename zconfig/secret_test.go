@@ -0,0 +1,43 @@
+package zconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+func TestRedactHookFile(t *testing.T) {
+	dir := t.TempDir()
+	err := zlog.Init(zlog.Config{Path: dir, Name: "app", Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zlog.AddHook(RedactHook{})
+
+	addSecret("s3cr3t-value")
+	zlog.WithField("dsn", "root:s3cr3t-value@tcp").Infof("connect with s3cr3t-value")
+	zlog.WithError(errors.New("dial root:s3cr3t-value@tcp failed")).
+		WithField("args", []any{"s3cr3t-value"}).Error("connect failed")
+	zlog.Flush(time.Second)
+
+	var content strings.Builder
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			bs, _ := os.ReadFile(path)
+			content.Write(bs)
+		}
+		return nil
+	})
+
+	if !strings.Contains(content.String(), "connect with") || !strings.Contains(content.String(), "connect failed") {
+		t.Fatalf("log not written: %q", content.String())
+	}
+	if strings.Contains(content.String(), "s3cr3t-value") {
+		t.Fatalf("secret not redacted: %q", content.String())
+	}
+}
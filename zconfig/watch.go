@@ -0,0 +1,158 @@
+package zconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+// 文件变化后等待写入完成的时长，避免读到写了一半的文件
+const watchDelay = 200 * time.Millisecond
+
+// Watcher 监听配置文件变化，重新加载并校验通过后原子替换当前配置，
+// 然后通知订阅者。校验失败时保留之前的配置。
+type Watcher struct {
+	loader  *Loader
+	typ     reflect.Type
+	check   func(c any) error
+	current atomic.Value
+	timer   *time.Timer
+
+	mu          sync.Mutex
+	subscribers []func(old any, new any)
+	watched     map[string]bool
+}
+
+// Watch 加载配置文件到 c 并开始监听文件变化，c 必须是结构体指针。
+// check 在每次重新加载后调用，可在其中校验配置及设置默认值，返回错误时放弃本次加载。
+func Watch(path string, c any, check func(c any) error) (*Watcher, error) {
+	return WatchLoader(&Loader{Path: path}, c, check)
+}
+
+// WatchLoader 同 Watch，通过 loader 分层加载配置，并监听其加载的所有配置文件
+func WatchLoader(loader *Loader, c any, check func(c any) error) (*Watcher, error) {
+	typ := reflect.TypeOf(c)
+	if typ == nil || typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config must be a pointer to struct")
+	}
+
+	err := loader.Load(c)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		loader:  loader,
+		typ:     typ.Elem(),
+		check:   check,
+		watched: make(map[string]bool),
+	}
+	w.current.Store(c)
+
+	w.timer = time.AfterFunc(time.Hour, w.reload)
+	w.timer.Stop()
+
+	err = w.watchFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// watchFiles 监听 loader 加载的配置文件中尚未监听的文件，重新加载后新 include 的文件也会被监听，调用前必须加锁或未开始监听
+func (w *Watcher) watchFiles() error {
+	for _, file := range w.loader.Files() {
+		if w.watched[file] {
+			continue
+		}
+
+		v := viper.New()
+		v.SetConfigFile(file)
+		err := v.ReadInConfig()
+		if err != nil {
+			return fmt.Errorf("read config [%s] failed [%v]", file, err)
+		}
+		v.OnConfigChange(func(fsnotify.Event) {
+			w.timer.Reset(watchDelay)
+		})
+		v.WatchConfig()
+
+		w.watched[file] = true
+	}
+
+	return nil
+}
+
+// Load 返回当前生效的配置
+func (w *Watcher) Load() any {
+	return w.current.Load()
+}
+
+// Subscribe 订阅配置变化，配置重新加载成功且内容发生变化时调用 f
+func (w *Watcher) Subscribe(f func(old any, new any)) {
+	w.mu.Lock()
+	w.subscribers = append(w.subscribers, f)
+	w.mu.Unlock()
+}
+
+// Reload 立即重新加载配置文件，订阅者在释放锁后调用，可以在订阅者中调用 Subscribe 和 Reload
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+
+	c := reflect.New(w.typ).Interface()
+
+	err := w.loader.LoadCheck(c, func(c any) error {
+		if w.check == nil {
+			return nil
+		}
+		err := w.check(c)
+		if err != nil {
+			return fmt.Errorf("check config [%s] failed [%v]", w.loader.Path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
+
+	err = w.watchFiles()
+	if err != nil {
+		zlog.Warnf("Watch config files failed, error: %v.", err)
+	}
+
+	old := w.current.Load()
+	if reflect.DeepEqual(old, c) {
+		w.mu.Unlock()
+		return nil
+	}
+	w.current.Store(c)
+
+	subscribers := make([]func(old any, new any), len(w.subscribers))
+	copy(subscribers, w.subscribers)
+
+	w.mu.Unlock()
+
+	for _, f := range subscribers {
+		f(old, c)
+	}
+
+	return nil
+}
+
+func (w *Watcher) reload() {
+	err := w.Reload()
+	if err != nil {
+		zlog.Errorf("Reload config failed, keep previous config, error: %v.", err)
+		return
+	}
+	zlog.Infof("Reload config succeed, path: %s.", w.loader.Path)
+}
@@ -9,6 +9,8 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"github.com/yyliziqiu/zlib/zhealth"
 )
 
 var (
@@ -34,6 +36,13 @@ func Init(configs ...Config) error {
 		}
 		_sqlDBS[config.Id] = db
 
+		zhealth.Register(zhealth.Probe{
+			Name:  "db." + config.Id,
+			Level: config.HealthLevel,
+			Check: db.PingContext,
+		})
+		registerMetrics(config.Id, db)
+
 		if !config.EnableORM {
 			continue
 		}
@@ -44,6 +53,12 @@ func Init(configs ...Config) error {
 			return err
 		}
 		_ormDBS[config.Id] = orm
+
+		err = registerTrace(orm, config)
+		if err != nil {
+			Finally()
+			return err
+		}
 	}
 
 	return nil
@@ -83,7 +98,9 @@ func NewORM(config Config, db *sql.DB) (*gorm.DB, error) {
 }
 
 func Finally() {
-	for _, db := range _sqlDBS {
+	for id, db := range _sqlDBS {
+		zhealth.Unregister("db." + id)
+		unregisterMetrics(id)
 		_ = db.Close()
 	}
 }
@@ -19,12 +19,13 @@ const (
 
 type Config struct {
 	Id              string        // optional
-	Type            string        // optional
-	DSN             string        // must
+	Type            string        `valid:"oneof=mysql postgres"` // optional
+	DSN             string        `valid:"required"`             // must
 	MaxOpenConns    int           // optional
 	MaxIdleConns    int           // optional
 	ConnMaxLifetime time.Duration // optional
 	ConnMaxIdleTime time.Duration // optional
+	HealthLevel     string        `valid:"oneof=critical noncritical none"` // optional, 参考 zhealth.LevelCritical
 
 	// only valid when use gorm
 	EnableORM                       bool           // optional
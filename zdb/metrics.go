@@ -0,0 +1,44 @@
+package zdb
+
+import (
+	"database/sql"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+var (
+	_maxOpenConns = zmetrics.NewGauge("zdb_max_open_connections", "Maximum number of open connections to the database.", "id")
+	_openConns    = zmetrics.NewGauge("zdb_open_connections", "Number of established connections, both in use and idle.", "id")
+	_inUseConns   = zmetrics.NewGauge("zdb_in_use_connections", "Number of connections currently in use.", "id")
+	_idleConns    = zmetrics.NewGauge("zdb_idle_connections", "Number of idle connections.", "id")
+	_waitCount    = zmetrics.NewCounter("zdb_wait_count_total", "Total number of connections waited for.", "id")
+	_waitDuration = zmetrics.NewCounter("zdb_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", "id")
+)
+
+var (
+	_poolGauges   = []*zmetrics.Gauge{_maxOpenConns, _openConns, _inUseConns, _idleConns}
+	_poolCounters = []*zmetrics.Counter{_waitCount, _waitDuration}
+)
+
+// registerMetrics 在每次输出指标时读取连接池状态
+func registerMetrics(id string, db *sql.DB) {
+	zmetrics.RegisterCollector("db."+id, func() {
+		stats := db.Stats()
+		_maxOpenConns.Set(float64(stats.MaxOpenConnections), id)
+		_openConns.Set(float64(stats.OpenConnections), id)
+		_inUseConns.Set(float64(stats.InUse), id)
+		_idleConns.Set(float64(stats.Idle), id)
+		_waitCount.Set(float64(stats.WaitCount), id)
+		_waitDuration.Set(stats.WaitDuration.Seconds(), id)
+	})
+}
+
+func unregisterMetrics(id string) {
+	zmetrics.UnregisterCollector("db." + id)
+	for _, gauge := range _poolGauges {
+		gauge.Delete(id)
+	}
+	for _, counter := range _poolCounters {
+		counter.Delete(id)
+	}
+}
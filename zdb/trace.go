@@ -0,0 +1,68 @@
+package zdb
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/yyliziqiu/zlib/ztrace"
+)
+
+// gorm 语句实例中保存 span 的键
+const traceSpanKey = "ztrace:span"
+
+// registerTrace 注册 gorm 回调，为每条语句创建 client span，父 span 取自 db.WithContext 传入的 ctx
+func registerTrace(orm *gorm.DB, config Config) error {
+	cb := orm.Callback()
+
+	err := errors.Join(
+		cb.Create().Before("gorm:create").Register("ztrace:before_create", traceBefore(config, "create")),
+		cb.Create().After("gorm:create").Register("ztrace:after_create", traceAfter),
+		cb.Query().Before("gorm:query").Register("ztrace:before_query", traceBefore(config, "query")),
+		cb.Query().After("gorm:query").Register("ztrace:after_query", traceAfter),
+		cb.Update().Before("gorm:update").Register("ztrace:before_update", traceBefore(config, "update")),
+		cb.Update().After("gorm:update").Register("ztrace:after_update", traceAfter),
+		cb.Delete().Before("gorm:delete").Register("ztrace:before_delete", traceBefore(config, "delete")),
+		cb.Delete().After("gorm:delete").Register("ztrace:after_delete", traceAfter),
+		cb.Row().Before("gorm:row").Register("ztrace:before_row", traceBefore(config, "row")),
+		cb.Row().After("gorm:row").Register("ztrace:after_row", traceAfter),
+		cb.Raw().Before("gorm:raw").Register("ztrace:before_raw", traceBefore(config, "raw")),
+		cb.Raw().After("gorm:raw").Register("ztrace:after_raw", traceAfter),
+	)
+	if err != nil {
+		return fmt.Errorf("register trace callbacks error [%v]", err)
+	}
+
+	return nil
+}
+
+func traceBefore(config Config, operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := ztrace.Start(db.Statement.Context, "gorm."+operation, ztrace.SpanKindClient)
+		span.SetAttribute("db.system", config.Type)
+		span.SetAttribute("db.operation", operation)
+		span.SetAttribute("zdb.id", config.Id)
+		db.InstanceSet(traceSpanKey, span)
+	}
+}
+
+func traceAfter(db *gorm.DB) {
+	val, ok := db.InstanceGet(traceSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := val.(*ztrace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttribute("db.sql.table", db.Statement.Table)
+	span.SetAttribute("db.statement", db.Statement.SQL.String())
+	span.SetAttribute("db.rows_affected", db.Statement.RowsAffected)
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		span.SetError(db.Error)
+	}
+
+	span.End()
+}
@@ -1,7 +1,11 @@
 package zelastic
 
 import (
+	"context"
+
 	"github.com/olivere/elastic/v7"
+
+	"github.com/yyliziqiu/zlib/zhealth"
 )
 
 var (
@@ -24,6 +28,19 @@ func Init(configs ...Config) error {
 			return err
 		}
 		_clients[config.Id] = client
+
+		url := elastic.DefaultURL
+		if len(config.Hosts) > 0 {
+			url = config.Hosts[0]
+		}
+		zhealth.Register(zhealth.Probe{
+			Name:  "elastic." + config.Id,
+			Level: config.HealthLevel,
+			Check: func(ctx context.Context) error {
+				_, _, err := client.Ping(url).Do(ctx)
+				return err
+			},
+		})
 	}
 
 	return nil
@@ -43,7 +60,8 @@ func NewClient(config Config) (*elastic.Client, error) {
 }
 
 func Finally() {
-	for _, client := range _clients {
+	for id, client := range _clients {
+		zhealth.Unregister("elastic." + id)
 		client.Stop()
 	}
 }
@@ -11,10 +11,11 @@ const DefaultId = "default"
 
 type Config struct {
 	Id           string   // optional
-	Hosts        []string // must
-	Username     string   // must
-	Password     string   // must
+	Hosts        []string `valid:"required,url"` // must
+	Username     string   `valid:"required"`     // must
+	Password     string   `valid:"required"`     // must
 	EnableLogger bool     // optional
+	HealthLevel  string   `valid:"oneof=critical noncritical none"` // optional, 参考 zhealth.LevelCritical
 
 	Logger *logrus.Logger `json:"-"` // optional
 	Client elastic.Doer   `json:"-"` // optional
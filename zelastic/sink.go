@@ -0,0 +1,118 @@
+package zelastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+func init() {
+	zlog.RegisterSender(zlog.SinkElastic, newSender)
+}
+
+// sender 通过 zelastic 客户端将日志批量写入 Elasticsearch，按天生成索引
+type sender struct {
+	clientId  string
+	index     string
+	formatter logrus.Formatter
+}
+
+func newSender(config zlog.SinkConfig, _ zlog.Config) (zlog.Sender, error) {
+	return &sender{
+		clientId: config.ClientId,
+		index:    config.Index,
+		formatter: &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyTime: "@timestamp"},
+		},
+	}, nil
+}
+
+// Encode 编码为 bulk 请求中的一组 action 和 document
+func (s *sender) Encode(entry *logrus.Entry) ([]byte, error) {
+	doc, err := s.formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := json.Marshal(map[string]any{
+		"index": map[string]string{"_index": s.index + "-" + entry.Time.Format("2006.01.02")},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, 0, len(action)+len(doc)+1)
+	record = append(record, action...)
+	record = append(record, '\n')
+	record = append(record, doc...)
+
+	return record, nil
+}
+
+// Send 每次发送时获取客户端，使 zlog 先于 zelastic 初始化时也能使用
+func (s *sender) Send(ctx context.Context, records [][]byte) error {
+	client := GetCli(s.clientId)
+	if client == nil {
+		return fmt.Errorf("elastic client [%s] not found", s.clientId)
+	}
+
+	res, err := client.PerformRequest(ctx, elastic.PerformRequestOptions{
+		Method:      "POST",
+		Path:        "/_bulk",
+		Body:        string(bytes.Join(records, nil)),
+		ContentType: "application/x-ndjson",
+	})
+	if err != nil {
+		return fmt.Errorf("bulk error [%v]", err)
+	}
+
+	var ret elastic.BulkResponse
+	err = json.Unmarshal(res.Body, &ret)
+	if err != nil {
+		return fmt.Errorf("decode bulk response error [%v]", err)
+	}
+	if !ret.Errors {
+		return nil
+	}
+
+	// 只重试因限流或服务端错误失败的记录，其余失败的记录重试也不会成功
+	var (
+		failed [][]byte
+		reason string
+	)
+	for i, item := range ret.Items {
+		if i >= len(records) {
+			break
+		}
+		for _, result := range item {
+			if result.Status < 300 {
+				continue
+			}
+			if result.Error != nil && reason == "" {
+				reason = result.Error.Reason
+			}
+			if result.Status == 429 || result.Status >= 500 {
+				failed = append(failed, records[i])
+			}
+		}
+	}
+	if len(failed) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Some logs rejected by elastic, reason: %s.\n", reason)
+		return nil
+	}
+
+	return &zlog.PartialError{Records: failed, Err: fmt.Errorf("bulk failed [%s]", reason)}
+}
+
+func (s *sender) Close() error {
+	return nil
+}
@@ -0,0 +1,199 @@
+package zhealth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zutil"
+)
+
+const (
+	// LevelCritical 检查失败时服务不健康
+	LevelCritical = "critical"
+
+	// LevelNonCritical 检查失败时服务降级，但仍然健康
+	LevelNonCritical = "noncritical"
+
+	// LevelNone 不注册检查
+	LevelNone = "none"
+
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+
+	DefaultTimeout = 3 * time.Second
+)
+
+// Probe 健康检查项
+type Probe struct {
+	Name    string                          // must
+	Level   string                          // optional, 默认为 LevelCritical
+	Timeout time.Duration                   // optional, 默认为 DefaultTimeout
+	Check   func(ctx context.Context) error // must
+}
+
+func (p Probe) Default() Probe {
+	if p.Level == "" {
+		p.Level = LevelCritical
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultTimeout
+	}
+	return p
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Cost   string `json:"cost"`
+}
+
+// Report 所有检查项的汇总结果
+type Report struct {
+	Status string   `json:"status"`
+	Ready  bool     `json:"ready"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy 没有关键检查项失败时为 true
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+var (
+	_mu     sync.RWMutex
+	_names  []string
+	_probes = make(map[string]Probe, 16)
+
+	_ready atomic.Bool
+)
+
+func init() {
+	_ready.Store(true)
+}
+
+// Register 注册检查项，同名检查项会被替换，Level 为 LevelNone 时忽略
+func Register(probe Probe) {
+	probe = probe.Default()
+	if probe.Level == LevelNone || probe.Check == nil {
+		return
+	}
+
+	_mu.Lock()
+	defer _mu.Unlock()
+
+	if _, ok := _probes[probe.Name]; !ok {
+		_names = append(_names, probe.Name)
+	}
+	_probes[probe.Name] = probe
+}
+
+// Unregister 注销检查项
+func Unregister(name string) {
+	_mu.Lock()
+	defer _mu.Unlock()
+
+	if _, ok := _probes[name]; !ok {
+		return
+	}
+	delete(_probes, name)
+	for i, n := range _names {
+		if n == name {
+			_names = append(_names[:i], _names[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetReady 设置服务是否就绪，应用启动完成前和优雅退出期间应为 false
+func SetReady(ready bool) {
+	_ready.Store(ready)
+}
+
+func Ready() bool {
+	return _ready.Load()
+}
+
+// Check 并发执行所有检查项并汇总结果
+func Check(ctx context.Context) Report {
+	_mu.RLock()
+	probes := make([]Probe, 0, len(_names))
+	for _, name := range _names {
+		probes = append(probes, _probes[name])
+	}
+	_mu.RUnlock()
+
+	results := make([]Result, len(probes))
+
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			results[i] = check(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Ready:  Ready(),
+		Checks: results,
+	}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Level == LevelCritical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+func check(ctx context.Context, probe Probe) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	timer := zutil.NewTimer()
+
+	result = Result{
+		Name:   probe.Name,
+		Level:  probe.Level,
+		Status: StatusUp,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- probe.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %s", probe.Timeout)
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	result.Cost = timer.Stops()
+
+	return result
+}
@@ -0,0 +1,307 @@
+package zhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+// 熔断器的区分方式
+const (
+	BreakerKeyHost    = "host"     // 按请求的目标主机区分
+	BreakerKeyBaseURL = "base_url" // 按 Client 的 baseURL 区分，baseURL 为空或请求使用完整 URL 时按主机区分
+)
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrCircuitOpen 熔断器打开时请求返回的错误满足 errors.Is(err, ErrCircuitOpen)
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// OpenError 熔断器打开时不发送请求，直接返回该错误
+type OpenError struct {
+	Key   string
+	Until time.Time // 熔断器进入半开状态的时间
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker [%s] is open until %s", e.Key, e.Until.Format(time.DateTime))
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerPolicy 熔断策略，连续失败次数或统计周期内的失败率达到阈值时打开熔断器，冷却 CoolDown 后进入半开状态，
+// 半开状态下连续 HalfOpenRequests 个请求成功时关闭熔断器，任一请求失败时重新打开
+type BreakerPolicy struct {
+	Key                 string        `valid:"oneof=host base_url"` // optional, 默认为 BreakerKeyHost
+	ConsecutiveFailures int           // optional, 连续失败次数阈值，默认为 5
+	FailureRate         float64       // optional, 失败率阈值，取值 0～1，默认为 0.5
+	MinRequests         int           // optional, 统计周期内请求数达到该值时才计算失败率，默认为 20
+	Window              time.Duration // optional, 失败率的统计周期，默认为 10s
+	CoolDown            time.Duration // optional, 打开后进入半开状态前的等待时间，默认为 30s
+	HalfOpenRequests    int           // optional, 半开状态下允许的请求数，默认为 1
+	// optional, 自定义请求是否失败，默认网络错误、429 和 5xx 响应为失败，调用方取消的请求不计入统计
+	IsFailure func(res *http.Response, err error) bool
+	// optional, 状态变化时调用
+	OnStateChange func(key string, from BreakerState, to BreakerState)
+}
+
+func (p BreakerPolicy) Default() BreakerPolicy {
+	if p.Key == "" {
+		p.Key = BreakerKeyHost
+	}
+	if p.ConsecutiveFailures <= 0 {
+		p.ConsecutiveFailures = 5
+	}
+	if p.FailureRate <= 0 || p.FailureRate > 1 {
+		p.FailureRate = 0.5
+	}
+	if p.MinRequests <= 0 {
+		p.MinRequests = 20
+	}
+	if p.Window <= 0 {
+		p.Window = 10 * time.Second
+	}
+	if p.CoolDown <= 0 {
+		p.CoolDown = 30 * time.Second
+	}
+	if p.HalfOpenRequests <= 0 {
+		p.HalfOpenRequests = 1
+	}
+	if p.IsFailure == nil {
+		p.IsFailure = isFailure
+	}
+	return p
+}
+
+func isFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+var _breakerState = zmetrics.NewGauge(
+	"zhttp_circuit_breaker_state",
+	"State of zhttp circuit breakers, 0 closed, 1 half-open, 2 open.",
+	"key",
+)
+
+// breakers 按 key 管理熔断器
+type breakers struct {
+	policy BreakerPolicy
+
+	mu   sync.Mutex
+	list map[string]*breaker
+}
+
+func newBreakers(policy BreakerPolicy) *breakers {
+	return &breakers{
+		policy: policy.Default(),
+		list:   make(map[string]*breaker),
+	}
+}
+
+func (bs *breakers) key(req *http.Request, baseURL string) string {
+	if bs.policy.Key == BreakerKeyBaseURL && baseURL != "" {
+		if u, err := url.Parse(baseURL); err == nil && u.Host == req.URL.Host {
+			return baseURL
+		}
+	}
+	return req.URL.Host
+}
+
+func (bs *breakers) get(key string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b, ok := bs.list[key]
+	if !ok {
+		b = &breaker{key: key, policy: &bs.policy, windowStart: time.Now()}
+		bs.list[key] = b
+	}
+
+	return b
+}
+
+func (bs *breakers) states() map[string]BreakerState {
+	bs.mu.Lock()
+	list := make([]*breaker, 0, len(bs.list))
+	for _, b := range bs.list {
+		list = append(list, b)
+	}
+	bs.mu.Unlock()
+
+	result := make(map[string]BreakerState, len(list))
+	for _, b := range list {
+		result[b.key] = b.currentState()
+	}
+
+	return result
+}
+
+type breaker struct {
+	key    string
+	policy *BreakerPolicy
+
+	mu          sync.Mutex
+	state       BreakerState
+	openedAt    time.Time
+	consecutive int // 连续失败次数
+	requests    int // 统计周期内的请求数
+	failures    int // 统计周期内的失败数
+	windowStart time.Time
+	halfOpen    int // 半开状态下已放行的请求数
+	successes   int // 半开状态下成功的请求数
+	transitions []transition
+}
+
+// allow 判断是否放行请求
+func (b *breaker) allow() error {
+	b.mu.Lock()
+
+	now := time.Now()
+	if b.state == StateOpen {
+		until := b.openedAt.Add(b.policy.CoolDown)
+		if now.Before(until) {
+			b.mu.Unlock()
+			return &OpenError{Key: b.key, Until: until}
+		}
+		b.setState(StateHalfOpen, now)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.halfOpen >= b.policy.HalfOpenRequests {
+			b.mu.Unlock()
+			return &OpenError{Key: b.key, Until: now}
+		}
+		b.halfOpen++
+	}
+
+	b.mu.Unlock()
+	b.notify()
+
+	return nil
+}
+
+// release 请求被调用方取消，目标主机可能未被访问，不计入统计，半开状态下归还放行名额
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.halfOpen > 0 {
+		b.halfOpen--
+	}
+}
+
+// record 记录请求结果
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+
+	now := time.Now()
+	switch b.state {
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, now)
+		} else {
+			b.successes++
+			if b.successes >= b.policy.HalfOpenRequests {
+				b.setState(StateClosed, now)
+			}
+		}
+	case StateClosed:
+		if now.Sub(b.windowStart) > b.policy.Window {
+			b.requests, b.failures, b.windowStart = 0, 0, now
+		}
+		b.requests++
+		if failed {
+			b.failures++
+			b.consecutive++
+		} else {
+			b.consecutive = 0
+		}
+		if b.consecutive >= b.policy.ConsecutiveFailures ||
+			(b.requests >= b.policy.MinRequests && float64(b.failures)/float64(b.requests) >= b.policy.FailureRate) {
+			b.setState(StateOpen, now)
+		}
+	}
+
+	b.mu.Unlock()
+	b.notify()
+}
+
+// transition 一次状态变化，由 notify 在锁外通知
+type transition struct {
+	from BreakerState
+	to   BreakerState
+}
+
+// setState 修改状态并重置计数，调用前必须加锁
+func (b *breaker) setState(state BreakerState, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	b.transitions = append(b.transitions, transition{from: b.state, to: state})
+	b.state = state
+	b.consecutive, b.requests, b.failures, b.windowStart = 0, 0, 0, now
+	b.halfOpen, b.successes = 0, 0
+	if state == StateOpen {
+		b.openedAt = now
+	}
+}
+
+// notify 在锁外调用状态变化的回调
+func (b *breaker) notify() {
+	b.mu.Lock()
+	transitions := b.transitions
+	b.transitions = nil
+	b.mu.Unlock()
+
+	for _, t := range transitions {
+		_breakerState.Set(float64(t.to), b.key)
+		if b.policy.OnStateChange != nil {
+			b.policy.OnStateChange(b.key, t.from, t.to)
+		}
+	}
+}
+
+func (b *breaker) currentState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// BreakerStates 返回所有熔断器的状态，没有开启熔断时返回 nil
+func (cli *Client) BreakerStates() map[string]BreakerState {
+	if cli.breakers == nil {
+		return nil
+	}
+	return cli.breakers.states()
+}
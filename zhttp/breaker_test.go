@@ -0,0 +1,115 @@
+package zhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var (
+		calls atomic.Int32
+		down  atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+	cli := New(WithBaseURL(server.URL), WithBreaker(BreakerPolicy{
+		Key:                 BreakerKeyBaseURL,
+		ConsecutiveFailures: 2,
+		CoolDown:            50 * time.Millisecond,
+		OnStateChange: func(key string, from BreakerState, to BreakerState) {
+			mu.Lock()
+			changes = append(changes, from.String()+"->"+to.String())
+			mu.Unlock()
+		},
+	}))
+
+	down.Store(true)
+	for i := 0; i < 2; i++ {
+		err := cli.Get("/", nil, nil, nil)
+		var re *ResponseError
+		if !errors.As(err, &re) {
+			t.Fatalf("expected response error, got %v", err)
+		}
+	}
+
+	err := cli.Get("/", nil, nil, nil)
+	var oe *OpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &oe) || oe.Key != server.URL {
+		t.Fatalf("expected open error, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls: %d, want 2", calls.Load())
+	}
+	if cli.BreakerStates()[server.URL] != StateOpen {
+		t.Errorf("states: %v", cli.BreakerStates())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	down.Store(false)
+	err = cli.Get("/", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(changes, ","); got != "closed->open,open->half-open,half-open->closed" {
+		t.Errorf("changes: %s", got)
+	}
+}
+
+func TestBreakerCanceled(t *testing.T) {
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	cli := New(WithBaseURL(server.URL), WithBreaker(BreakerPolicy{ConsecutiveFailures: 1, CoolDown: 20 * time.Millisecond}))
+	key := strings.TrimPrefix(server.URL, "http://")
+
+	down.Store(true)
+	_ = cli.Get("/", nil, nil, nil)
+	time.Sleep(30 * time.Millisecond)
+	down.Store(false)
+
+	// 半开状态下取消的请求不关闭熔断器，并归还放行名额
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := cli.GetCtx(ctx, "/", nil, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if cli.BreakerStates()[key] != StateHalfOpen {
+		t.Fatalf("states: %v", cli.BreakerStates())
+	}
+
+	err = cli.Get("/", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cli.BreakerStates()[key] != StateClosed {
+		t.Fatalf("states: %v", cli.BreakerStates())
+	}
+}
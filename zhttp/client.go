@@ -2,7 +2,9 @@ package zhttp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,11 +12,15 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zredact"
+	"github.com/yyliziqiu/zlib/ztrace"
 	"github.com/yyliziqiu/zlib/zutil"
 )
 
@@ -28,10 +34,15 @@ type Client struct {
 	logger        *logrus.Logger                 // 如果为 nil，则不记录日志
 	format        string                         // 响应报文格式
 	error         error                          // 响应失败时的 JSON 结构。在响应成功和失败时 JSON 结构不一致时设置，不能是指针
+	envelope      *EnvelopeDecoder               // 响应的外层结构，如果为 nil，则直接解析响应
 	dumps         bool                           // 将 HTTP 报文打印到控制台
 	baseURL       string                         // URL 前缀
 	logLength     int                            // 最大日志长度
 	logEscape     bool                           // 是否转换日志中的特殊字符
+	redactor      *zredact.Redactor              // 日志脱敏，如果为 nil，则使用 zredact.Default()
+	retry         *RetryPolicy                   // 重试策略，如果为 nil，则不重试
+	breakers      *breakers                      // 熔断器，如果为 nil，则不熔断
+	middlewares   []Middleware                   // 按顺序拦截每次发送的请求
 	requestBefore func(req *http.Request)        // 在发送请求前调用
 	responseAfter func(res *http.Response) error // 在接收响应后调用
 }
@@ -46,10 +57,15 @@ func New(options ...Option) *Client {
 		logger:        nil,
 		format:        FormatJSON,
 		error:         nil,
+		envelope:      nil,
 		dumps:         false,
 		baseURL:       "",
 		logLength:     1024,
 		logEscape:     false,
+		redactor:      nil,
+		retry:         nil,
+		breakers:      nil,
+		middlewares:   nil,
 		requestBefore: nil,
 		responseAfter: nil,
 	}
@@ -61,20 +77,20 @@ func New(options ...Option) *Client {
 	return client
 }
 
-func (cli *Client) newRequest(method string, path string, query url.Values, header http.Header, body io.Reader) (*http.Request, error) {
+func (cli *Client) newRequest(ctx context.Context, method string, path string, query url.Values, header http.Header, body io.Reader) (*http.Request, error) {
 	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
 		path = JoinURL(cli.baseURL, path)
 	}
 
 	url2, err := AppendQuery(path, query)
 	if err != nil {
-		cli.logWarn("Append query failed, URL: %s, query: %s, error: %v.", url2, query.Encode(), err)
+		cli.logWarn(ctx, "Append query failed, URL: %s, query: %s, error: %v.", url2, query.Encode(), err)
 		return nil, fmt.Errorf("append query error [%v]", err)
 	}
 
-	req, err := http.NewRequest(method, url2, body)
+	req, err := http.NewRequestWithContext(ctx, method, url2, body)
 	if err != nil {
-		cli.logWarn("New request failed, URL: %s, error: %v.", url2, err)
+		cli.logWarn(ctx, "New request failed, URL: %s, error: %v.", url2, err)
 		return nil, fmt.Errorf("new request error [%v]", err)
 	}
 
@@ -92,13 +108,136 @@ func (cli *Client) newRequest(method string, path string, query url.Values, head
 }
 
 func (cli *Client) doRequest(req *http.Request) (*http.Response, error) {
+	if cli.retry == nil || !cli.retry.allowMethod(req.Method) {
+		res, err := cli.doAttempt(req)
+		if err != nil {
+			cli.logWarn(req.Context(), "Do request failed, URL: %s, error: %v.", req.URL, err)
+		}
+		return res, err
+	}
+
+	err := cli.replayable(req)
+	if err != nil {
+		return nil, fmt.Errorf("read request body error [%v]", err)
+	}
+
+	for n := 1; ; n++ {
+		attemptReq := req.WithContext(context.WithValue(req.Context(), attemptKey{}, n))
+		if n > 1 && req.GetBody != nil {
+			attemptReq.Body, err = req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("replay request body error [%v]", err)
+			}
+		}
+
+		timer := zutil.NewTimer()
+
+		res, err := cli.doAttempt(attemptReq)
+		if n >= cli.retry.MaxAttempts || req.Context().Err() != nil || !cli.retry.shouldRetry(res, err) {
+			if err != nil {
+				cli.logWarn(req.Context(), "Do request failed, URL: %s, attempt: %d, error: %v.", req.URL, n, err)
+			}
+			return res, err
+		}
+
+		wait := cli.retry.backoff(n)
+		if err == nil {
+			// 服务端要求的等待时间超出 MaxBackoff 时不再重试
+			after := retryAfter(res)
+			if after > cli.retry.MaxBackoff {
+				return res, nil
+			}
+			if after > wait {
+				wait = after
+			}
+		}
+
+		cli.logAttempt(attemptReq, res, err, timer.Stops())
+
+		if !sleepContext(req.Context(), wait) {
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// logAttempt 记录将要重试的请求
+func (cli *Client) logAttempt(req *http.Request, res *http.Response, err error, cost string) {
+	var body []byte
+	if res != nil {
+		body, _ = io.ReadAll(io.LimitReader(res.Body, int64(cli.logLength)))
+		_ = res.Body.Close()
+		err = newResponseError(res.StatusCode, string(body))
+	}
+
+	var reqBody []byte
+	if req.GetBody != nil && cli.logger != nil {
+		if rc, err2 := req.GetBody(); err2 == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(rc, int64(cli.logLength)))
+			_ = rc.Close()
+		}
+	}
+
+	cli.logHTTP(HTTPLog{
+		Method:       req.Method,
+		Request:      req,
+		RequestBody:  reqBody,
+		Response:     res,
+		ResponseBody: body,
+		Error:        err,
+		Cost:         cost,
+	})
+}
+
+// doAttempt 发送一次请求
+func (cli *Client) doAttempt(req *http.Request) (*http.Response, error) {
+	if cli.breakers == nil {
+		return cli.send(req)
+	}
+
+	b := cli.breakers.get(cli.breakers.key(req, cli.baseURL))
+	err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cli.send(req)
+	if errors.Is(err, context.Canceled) && errors.Is(context.Cause(req.Context()), context.Canceled) {
+		b.release()
+	} else {
+		b.record(cli.breakers.policy.IsFailure(res, err))
+	}
+
+	return res, err
+}
+
+func (cli *Client) send(req *http.Request) (*http.Response, error) {
 	cli.dumpRequest(req)
 
-	res, err := cli.client.Do(req)
+	ctx, span := ztrace.Start(req.Context(), "HTTP "+req.Method, ztrace.SpanKindClient)
+	defer span.End()
+
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.Redacted())
+	span.SetAttribute("net.peer.name", req.URL.Hostname())
+	ztrace.Inject(ctx, ztrace.HeaderCarrier(req.Header))
+	if id := zlog.RequestId(ctx); id != "" && req.Header.Get(zlog.RequestIdHeader) == "" {
+		req.Header.Set(zlog.RequestIdHeader, id)
+	}
+
+	start := time.Now()
+
+	res, err := cli.chain(cli.client.Do)(req.WithContext(ctx))
 	if err != nil {
-		cli.logWarn("Do request failed, URL: %s, error: %v.", req.URL, err)
+		observeRequest(req, "error", start)
+		span.SetError(err)
 		return nil, err
 	}
+	observeRequest(req, strconv.Itoa(res.StatusCode), start)
+
+	span.SetAttribute("http.status_code", res.StatusCode)
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(ztrace.StatusError, res.Status)
+	}
 
 	return res, nil
 }
@@ -128,6 +267,9 @@ func (cli *Client) handleResponse(res *http.Response, out interface{}) ([]byte,
 
 func (cli *Client) handleJSONResponse(statusCode int, body []byte, out interface{}) error {
 	if statusCode/100 == 2 {
+		if cli.envelope != nil {
+			return cli.envelope.decode(statusCode, body, out)
+		}
 		if out != nil {
 			err := json.Unmarshal(body, out)
 			if err != nil {
@@ -151,6 +293,11 @@ func (cli *Client) handleJSONResponse(statusCode int, body []byte, out interface
 			if err == nil {
 				return ret.(error)
 			}
+		} else if cli.envelope != nil {
+			err := cli.envelope.decodeError(statusCode, body)
+			if err != nil {
+				return err
+			}
 		} else if out != nil {
 			err := json.Unmarshal(body, out)
 			if err == nil {
@@ -182,8 +329,8 @@ func (cli *Client) handleTextResponse(statusCode int, body []byte, out interface
 	return nil
 }
 
-func (cli *Client) get(method string, path string, query url.Values, header http.Header, out interface{}) error {
-	req, err := cli.newRequest(method, path, query, header, nil)
+func (cli *Client) get(ctx context.Context, method string, path string, query url.Values, header http.Header, out interface{}) error {
+	req, err := cli.newRequest(ctx, method, path, query, header, nil)
 	if err != nil {
 		return err
 	}
@@ -211,7 +358,7 @@ func (cli *Client) get(method string, path string, query url.Values, header http
 	return err
 }
 
-func (cli *Client) post(method string, path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
+func (cli *Client) post(ctx context.Context, method string, path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
 	if in == nil {
 		in = struct{}{}
 	}
@@ -220,7 +367,7 @@ func (cli *Client) post(method string, path string, query url.Values, header htt
 		return fmt.Errorf("marshal request body error [%v]", err)
 	}
 
-	req, err := cli.newRequest(method, path, query, header, bytes.NewReader(reqBody))
+	req, err := cli.newRequest(ctx, method, path, query, header, bytes.NewReader(reqBody))
 	if err != nil {
 		return err
 	}
@@ -250,23 +397,43 @@ func (cli *Client) post(method string, path string, query url.Values, header htt
 }
 
 func (cli *Client) Get(path string, query url.Values, header http.Header, out interface{}) error {
-	return cli.get(http.MethodGet, path, query, header, out)
+	return cli.GetCtx(context.Background(), path, query, header, out)
+}
+
+func (cli *Client) GetCtx(ctx context.Context, path string, query url.Values, header http.Header, out interface{}) error {
+	return cli.get(ctx, http.MethodGet, path, query, header, out)
 }
 
 func (cli *Client) Post(path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
-	return cli.post(http.MethodPost, path, query, header, in, out)
+	return cli.PostCtx(context.Background(), path, query, header, in, out)
+}
+
+func (cli *Client) PostCtx(ctx context.Context, path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
+	return cli.post(ctx, http.MethodPost, path, query, header, in, out)
 }
 
 func (cli *Client) Put(path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
-	return cli.post(http.MethodPut, path, query, header, in, out)
+	return cli.PutCtx(context.Background(), path, query, header, in, out)
+}
+
+func (cli *Client) PutCtx(ctx context.Context, path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
+	return cli.post(ctx, http.MethodPut, path, query, header, in, out)
 }
 
 func (cli *Client) Delete(path string, query url.Values, header http.Header, out interface{}) error {
-	return cli.get(http.MethodDelete, path, query, header, out)
+	return cli.DeleteCtx(context.Background(), path, query, header, out)
+}
+
+func (cli *Client) DeleteCtx(ctx context.Context, path string, query url.Values, header http.Header, out interface{}) error {
+	return cli.get(ctx, http.MethodDelete, path, query, header, out)
 }
 
 func (cli *Client) GetBinary(path string, query url.Values, header http.Header) ([]byte, string, error) {
-	req, err := cli.newRequest(http.MethodGet, path, query, header, nil)
+	return cli.GetBinaryCtx(context.Background(), path, query, header)
+}
+
+func (cli *Client) GetBinaryCtx(ctx context.Context, path string, query url.Values, header http.Header) ([]byte, string, error) {
+	req, err := cli.newRequest(ctx, http.MethodGet, path, query, header, nil)
 	if err != nil {
 		return nil, "", err
 	}
@@ -296,13 +463,21 @@ func (cli *Client) GetBinary(path string, query url.Values, header http.Header)
 }
 
 func (cli *Client) PostJSON(path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
-	return cli.Post(path, query, header, in, out)
+	return cli.PostJSONCtx(context.Background(), path, query, header, in, out)
+}
+
+func (cli *Client) PostJSONCtx(ctx context.Context, path string, query url.Values, header http.Header, in interface{}, out interface{}) error {
+	return cli.PostCtx(ctx, path, query, header, in, out)
 }
 
 func (cli *Client) PostForm(path string, query url.Values, header http.Header, in url.Values, out interface{}) error {
+	return cli.PostFormCtx(context.Background(), path, query, header, in, out)
+}
+
+func (cli *Client) PostFormCtx(ctx context.Context, path string, query url.Values, header http.Header, in url.Values, out interface{}) error {
 	reqBody := in.Encode()
 
-	req, err := cli.newRequest(http.MethodPost, path, query, header, strings.NewReader(reqBody))
+	req, err := cli.newRequest(ctx, http.MethodPost, path, query, header, strings.NewReader(reqBody))
 	if err != nil {
 		return err
 	}
@@ -333,41 +508,40 @@ func (cli *Client) PostForm(path string, query url.Values, header http.Header, i
 }
 
 func (cli *Client) PostFormData(path string, query url.Values, header http.Header, values map[string]string, files map[string]string, out interface{}) error {
-	var (
-		buf    bytes.Buffer
-		writer = multipart.NewWriter(&buf)
-	)
+	return cli.PostFormDataCtx(context.Background(), path, query, header, values, files, out)
+}
 
-	if len(values) > 0 {
+// PostFormDataCtx 以 multipart 表单上传 files 中的文件，文件从磁盘流式读取，Client 的超时时间只限制建立连接和等待响应头，上传只由 ctx 限制
+func (cli *Client) PostFormDataCtx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, files map[string]string, out interface{}) error {
+	body, contentType := multipartBody(func(writer *multipart.Writer) error {
 		for key, value := range values {
 			err := writer.WriteField(key, value)
 			if err != nil {
 				return err
 			}
 		}
-	}
-	if len(files) > 0 {
 		for key, file := range files {
 			err := cli.writeFormFile(writer, key, file)
 			if err != nil {
 				return err
 			}
 		}
-	}
-	err := writer.Close()
-	if err != nil {
-		return err
-	}
+		return nil
+	})
+	defer body.Close()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
-	req, err := cli.newRequest(http.MethodPost, path, query, header, &buf)
+	req, err := cli.newRequest(ctx, http.MethodPost, path, query, header, body)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
 
 	timer := zutil.NewTimer()
 
-	res, err := cli.doRequest(req)
+	res, err := cli.doStreaming(req, cancel)
 	if err != nil {
 		return err
 	}
@@ -406,7 +580,7 @@ func (cli *Client) PostFormData(path string, query url.Values, header http.Heade
 func (cli *Client) writeFormFile(writer *multipart.Writer, key string, path string) error {
 	file, err := os.Open(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer file.Close()
 
@@ -424,7 +598,15 @@ func (cli *Client) writeFormFile(writer *multipart.Writer, key string, path stri
 }
 
 func (cli *Client) PostBinary(path string, query url.Values, header http.Header, mimeType string, in io.Reader, out interface{}) error {
-	req, err := cli.newRequest(http.MethodPost, path, query, header, in)
+	return cli.PostBinaryCtx(context.Background(), path, query, header, mimeType, in, out)
+}
+
+// PostBinaryCtx 上传 in 的内容，Client 的超时时间只限制建立连接和等待响应头，上传只由 ctx 限制
+func (cli *Client) PostBinaryCtx(ctx context.Context, path string, query url.Values, header http.Header, mimeType string, in io.Reader, out interface{}) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := cli.newRequest(ctx, http.MethodPost, path, query, header, in)
 	if err != nil {
 		return err
 	}
@@ -432,7 +614,7 @@ func (cli *Client) PostBinary(path string, query url.Values, header http.Header,
 
 	timer := zutil.NewTimer()
 
-	res, err := cli.doRequest(req)
+	res, err := cli.doStreaming(req, cancel)
 	if err != nil {
 		return err
 	}
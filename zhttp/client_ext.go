@@ -1,7 +1,7 @@
 package zhttp
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +16,7 @@ import (
 )
 
 func (cli *Client) postStream(
+	ctx context.Context,
 	path string,
 	query url.Values,
 	header http.Header,
@@ -26,48 +27,44 @@ func (cli *Client) postStream(
 	stream io.Reader,
 	out interface{},
 ) error {
-	var (
-		buf    bytes.Buffer
-		writer = multipart.NewWriter(&buf)
-	)
-
-	if len(values) > 0 {
+	body, contentType := multipartBody(func(writer *multipart.Writer) error {
 		for key, value := range values {
 			err := writer.WriteField(key, value)
 			if err != nil {
 				return err
 			}
 		}
-	}
 
-	if stream != nil {
-		h := make(textproto.MIMEHeader)
-		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, EscapeQuotes(field), EscapeQuotes(filename)))
-		h.Set("Content-Type", mimeType)
-		part, err := writer.CreatePart(h)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(part, stream)
-		if err != nil {
-			return err
+		if stream != nil {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, EscapeQuotes(field), EscapeQuotes(filename)))
+			h.Set("Content-Type", mimeType)
+			part, err := writer.CreatePart(h)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(part, stream)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	err := writer.Close()
-	if err != nil {
-		return err
-	}
+		return nil
+	})
+	defer body.Close()
 
-	req, err := cli.newRequest(http.MethodPost, path, query, header, &buf)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := cli.newRequest(ctx, http.MethodPost, path, query, header, body)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
 
 	timer := zutil.NewTimer()
 
-	res, err := cli.doRequest(req)
+	res, err := cli.doStreaming(req, cancel)
 	if err != nil {
 		return err
 	}
@@ -96,40 +93,78 @@ func (cli *Client) postStream(
 }
 
 func (cli *Client) PostStream(path string, query url.Values, header http.Header, values map[string]string, field string, filename string, stream io.Reader, out interface{}) error {
+	return cli.PostStreamCtx(context.Background(), path, query, header, values, field, filename, stream, out)
+}
+
+func (cli *Client) PostStreamCtx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, field string, filename string, stream io.Reader, out interface{}) error {
 	mimeType := zmime.Get(filename)
-	return cli.postStream(path, query, header, values, field, filename, mimeType, stream, out)
+	return cli.postStream(ctx, path, query, header, values, field, filename, mimeType, stream, out)
 }
 
 func (cli *Client) PostStream2(path string, query url.Values, header http.Header, values map[string]string, field string, filename string, stream io.Reader, out interface{}) error {
+	return cli.PostStream2Ctx(context.Background(), path, query, header, values, field, filename, stream, out)
+}
+
+func (cli *Client) PostStream2Ctx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, field string, filename string, stream io.Reader, out interface{}) error {
 	mimeType := "application/octet-stream"
-	return cli.postStream(path, query, header, values, field, filename, mimeType, stream, out)
+	return cli.postStream(ctx, path, query, header, values, field, filename, mimeType, stream, out)
 }
 
 func (cli *Client) PostFile(path string, query url.Values, header http.Header, values map[string]string, field string, filepath string, out interface{}) error {
+	return cli.PostFileCtx(context.Background(), path, query, header, values, field, filepath, out)
+}
+
+func (cli *Client) PostFileCtx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, field string, filepath string, out interface{}) error {
 	files := map[string]string{field: filepath}
-	return cli.PostFormData(path, query, header, values, files, out)
+	return cli.PostFormDataCtx(ctx, path, query, header, values, files, out)
 }
 
 func (cli *Client) PostBinaryFormURL(path string, query url.Values, header http.Header, url string, out interface{}) error {
-	data, typ, err := cli.GetBinary(url, nil, nil)
+	return cli.PostBinaryFormURLCtx(context.Background(), path, query, header, url, out)
+}
+
+// PostBinaryFormURLCtx 边下载 url 边上传，Client 的超时时间只限制建立连接和等待响应头，下载和上传的总时长只由 ctx 限制
+func (cli *Client) PostBinaryFormURLCtx(ctx context.Context, path string, query url.Values, header http.Header, url string, out interface{}) error {
+	s, err := cli.openStream(ctx, http.MethodGet, url, nil, nil, nil)
 	if err != nil {
 		return err
 	}
-	return cli.PostBinary(path, query, header, typ, bytes.NewReader(data), out)
+
+	err = cli.PostBinaryCtx(ctx, path, query, header, s.res.Header.Get("Content-Type"), s.res.Body, out)
+	s.close(err)
+
+	return err
 }
 
 func (cli *Client) PostStreamFormURL(path string, query url.Values, header http.Header, values map[string]string, field string, url string, out interface{}) error {
-	data, _, err := cli.GetBinary(url, nil, nil)
+	return cli.PostStreamFormURLCtx(context.Background(), path, query, header, values, field, url, out)
+}
+
+// PostStreamFormURLCtx 同 PostBinaryFormURLCtx，以 multipart 表单上传
+func (cli *Client) PostStreamFormURLCtx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, field string, url string, out interface{}) error {
+	s, err := cli.openStream(ctx, http.MethodGet, url, nil, nil, nil)
 	if err != nil {
 		return err
 	}
-	return cli.PostStream(path, query, header, values, field, filepath.Base(url), bytes.NewReader(data), out)
+
+	err = cli.PostStreamCtx(ctx, path, query, header, values, field, filepath.Base(url), s.res.Body, out)
+	s.close(err)
+
+	return err
 }
 
 func (cli *Client) PostStreamFormURL2(path string, query url.Values, header http.Header, values map[string]string, field string, url string, out interface{}) error {
-	data, _, err := cli.GetBinary(url, nil, nil)
+	return cli.PostStreamFormURL2Ctx(context.Background(), path, query, header, values, field, url, out)
+}
+
+func (cli *Client) PostStreamFormURL2Ctx(ctx context.Context, path string, query url.Values, header http.Header, values map[string]string, field string, url string, out interface{}) error {
+	s, err := cli.openStream(ctx, http.MethodGet, url, nil, nil, nil)
 	if err != nil {
 		return err
 	}
-	return cli.PostStream2(path, query, header, values, field, filepath.Base(url), bytes.NewReader(data), out)
+
+	err = cli.PostStream2Ctx(ctx, path, query, header, values, field, filepath.Base(url), s.res.Body, out)
+	s.close(err)
+
+	return err
 }
@@ -1,10 +1,13 @@
 package zhttp
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"strings"
+
+	"github.com/yyliziqiu/zlib/zredact"
 )
 
 func (cli *Client) logHTTP(log HTTPLog) {
@@ -12,7 +15,7 @@ func (cli *Client) logHTTP(log HTTPLog) {
 		return
 	}
 
-	headers, reqBody, resBody := SerializeHeader(log.Request.Header), "", ""
+	headers, reqBody, resBody := SerializeHeader(cli.redact().Header(log.Request.Header)), "", ""
 	if len(log.RequestBody) > 0 {
 		reqBody = string(log.RequestBody)
 	}
@@ -20,29 +23,39 @@ func (cli *Client) logHTTP(log HTTPLog) {
 		resBody = string(log.ResponseBody)
 	}
 
+	status := 0
+	if log.Response != nil {
+		status = log.Response.StatusCode
+	}
+
+	cost := log.Cost
+	if n := attempt(log.Request, log.Response); n > 0 {
+		cost = fmt.Sprintf("%s, attempt: %d", cost, n)
+	}
+
 	if log.Error == nil {
-		cli.logInfo("Request succeed(%d), method: %s, url: %s, header: %s, request: %s, response: %s, cost: %s.",
-			log.Response.StatusCode, log.Method, log.Request.URL, headers, reqBody, resBody, log.Cost)
+		cli.logInfo(log.Request.Context(), "Request succeed(%d), method: %s, url: %s, header: %s, request: %s, response: %s, cost: %s.",
+			status, log.Method, log.Request.URL, headers, reqBody, resBody, cost)
 	} else {
-		cli.logWarn("Request failed(%d), method: %s, url: %s, header: %s, request: %s, response: %s, error: %v, cost: %s.",
-			log.Response.StatusCode, log.Method, log.Request.URL, headers, reqBody, resBody, log.Error, log.Cost)
+		cli.logWarn(log.Request.Context(), "Request failed(%d), method: %s, url: %s, header: %s, request: %s, response: %s, error: %v, cost: %s.",
+			status, log.Method, log.Request.URL, headers, reqBody, resBody, log.Error, cost)
 	}
 }
 
-func (cli *Client) logInfo(format string, args ...interface{}) {
+func (cli *Client) logInfo(ctx context.Context, format string, args ...interface{}) {
 	if cli.logger == nil {
 		return
 	}
 	message := cli.logCheck(fmt.Sprintf(format, args...))
-	cli.logger.Info(message)
+	cli.logger.WithContext(ctx).Info(message)
 }
 
-func (cli *Client) logWarn(format string, args ...interface{}) {
+func (cli *Client) logWarn(ctx context.Context, format string, args ...interface{}) {
 	if cli.logger == nil {
 		return
 	}
 	message := cli.logCheck(fmt.Sprintf(format, args...))
-	cli.logger.Warn(message)
+	cli.logger.WithContext(ctx).Warn(message)
 }
 
 func (cli *Client) logCheck(log string) string {
@@ -50,6 +63,9 @@ func (cli *Client) logCheck(log string) string {
 		return ""
 	}
 
+	// 先脱敏再截断，避免敏感内容因截断而无法匹配
+	log = cli.redact().String(log)
+
 	if len(log) > cli.logLength {
 		log = log[:cli.logLength]
 	}
@@ -63,6 +79,13 @@ func (cli *Client) logCheck(log string) string {
 	return log
 }
 
+func (cli *Client) redact() *zredact.Redactor {
+	if cli.redactor != nil {
+		return cli.redactor
+	}
+	return zredact.Default()
+}
+
 func (cli *Client) dumpRequest(req *http.Request) {
 	if !cli.dumps {
 		return
@@ -0,0 +1,130 @@
+package zhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// EnvelopeDecoder 解析形如 {"code": 0, "message": "ok", "data": {...}} 的响应，
+// 通过 WithEnvelope 设置后，响应成功时只将 data 解析到 out，code 表示失败时返回 *EnvelopeError，
+// 缺少 code 字段，或需要解析 data 但缺少 data 字段时返回错误，避免响应格式不符时静默返回零值
+type EnvelopeDecoder struct {
+	CodeField    string // optional, 默认为 code
+	MessageField string // optional, 默认为 message
+	DataField    string // optional, 默认为 data
+	// optional, 判断 code 是否表示成功，数字和字符串类型的 code 都会转换为字符串，
+	// 默认 code 为 0 或空字符串时成功
+	Success func(code string) bool
+}
+
+func (d EnvelopeDecoder) Default() EnvelopeDecoder {
+	if d.CodeField == "" {
+		d.CodeField = "code"
+	}
+	if d.MessageField == "" {
+		d.MessageField = "message"
+	}
+	if d.DataField == "" {
+		d.DataField = "data"
+	}
+	if d.Success == nil {
+		d.Success = isSuccessCode
+	}
+	return d
+}
+
+func isSuccessCode(code string) bool {
+	return code == "" || code == "0"
+}
+
+// EnvelopeError code 表示失败时返回的错误
+type EnvelopeError struct {
+	status int
+
+	Code    string
+	Message string
+}
+
+func (e *EnvelopeError) Status() int {
+	return e.status
+}
+
+func (e *EnvelopeError) Error() string {
+	return fmt.Sprintf("[%d] code: %s, message: %s", e.status, e.Code, e.Message)
+}
+
+// decode 解析响应，code 表示成功时将 data 解析到 out
+func (d *EnvelopeDecoder) decode(status int, body []byte, out interface{}) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return fmt.Errorf("unmarshal response error [%v]", err)
+	}
+
+	raw, ok := fields[d.CodeField]
+	if !ok {
+		return fmt.Errorf("response missing field [%s]", d.CodeField)
+	}
+	code := rawString(raw)
+	if !d.Success(code) {
+		return &EnvelopeError{status: status, Code: code, Message: rawString(fields[d.MessageField])}
+	}
+
+	if out == nil {
+		return nil
+	}
+	data, ok := fields[d.DataField]
+	if !ok {
+		return fmt.Errorf("response missing field [%s]", d.DataField)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("unmarshal response data error [%v]", err)
+	}
+
+	return nil
+}
+
+// decodeError 解析失败响应，code 存在且表示失败时返回 *EnvelopeError，否则返回 nil
+func (d *EnvelopeDecoder) decodeError(status int, body []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil
+	}
+
+	raw, ok := fields[d.CodeField]
+	if !ok {
+		return nil
+	}
+	code := rawString(raw)
+	if d.Success(code) {
+		return nil
+	}
+
+	return &EnvelopeError{status: status, Code: code, Message: rawString(fields[d.MessageField])}
+}
+
+// rawString 将 JSON 字符串转换为字符串，其他类型的值保留原文，如 0 转换为 "0"
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// DecodeEnvelope 使用 d 解析 body 并返回 data
+func DecodeEnvelope[T any](d EnvelopeDecoder, body []byte) (T, error) {
+	var out T
+	d = d.Default()
+	err := d.decode(0, body, &out)
+	return out, err
+}
@@ -0,0 +1,52 @@
+package zhttp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// jsonClient 返回按 JSON 解析响应的 Client，泛型函数不受 WithFormat 的影响
+func (cli *Client) jsonClient() *Client {
+	if cli.format == FormatJSON {
+		return cli
+	}
+	c := *cli
+	c.format = FormatJSON
+	return &c
+}
+
+// GetJSON 发送 GET 请求并将响应解析为 T，设置了 WithEnvelope 时解析响应中的 data
+func GetJSON[T any](ctx context.Context, cli *Client, path string, query url.Values, header http.Header) (T, error) {
+	var out T
+	err := cli.jsonClient().get(ctx, http.MethodGet, path, query, header, &out)
+	return out, err
+}
+
+// DeleteJSON 发送 DELETE 请求并将响应解析为 T
+func DeleteJSON[T any](ctx context.Context, cli *Client, path string, query url.Values, header http.Header) (T, error) {
+	var out T
+	err := cli.jsonClient().get(ctx, http.MethodDelete, path, query, header, &out)
+	return out, err
+}
+
+// PostJSON 将 in 编码为 JSON 发送 POST 请求并将响应解析为 Out
+func PostJSON[In any, Out any](ctx context.Context, cli *Client, path string, query url.Values, header http.Header, in In) (Out, error) {
+	var out Out
+	err := cli.jsonClient().post(ctx, http.MethodPost, path, query, header, in, &out)
+	return out, err
+}
+
+// PutJSON 将 in 编码为 JSON 发送 PUT 请求并将响应解析为 Out
+func PutJSON[In any, Out any](ctx context.Context, cli *Client, path string, query url.Values, header http.Header, in In) (Out, error) {
+	var out Out
+	err := cli.jsonClient().post(ctx, http.MethodPut, path, query, header, in, &out)
+	return out, err
+}
+
+// PatchJSON 将 in 编码为 JSON 发送 PATCH 请求并将响应解析为 Out
+func PatchJSON[In any, Out any](ctx context.Context, cli *Client, path string, query url.Values, header http.Header, in In) (Out, error) {
+	var out Out
+	err := cli.jsonClient().post(ctx, http.MethodPatch, path, query, header, in, &out)
+	return out, err
+}
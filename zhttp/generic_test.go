@@ -0,0 +1,87 @@
+package zhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type genericUser struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGenericJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plain":
+			_ = json.NewEncoder(w).Encode(genericUser{Id: 1, Name: "a"})
+		case "/echo":
+			var in genericUser
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":` + mustJSON(in) + `}`))
+		case "/failed":
+			_, _ = w.Write([]byte(`{"code":"A0001","message":"not found"}`))
+		case "/error":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"message":"bad request"}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	user, err := GetJSON[genericUser](ctx, New(WithBaseURL(server.URL)), "/plain", nil, nil)
+	if err != nil || user.Name != "a" {
+		t.Fatalf("user: %v, error: %v", user, err)
+	}
+
+	user, err = GetJSON[genericUser](ctx, New(WithBaseURL(server.URL), WithFormat(FormatText)), "/plain", nil, nil)
+	if err != nil || user.Name != "a" {
+		t.Fatalf("text client, user: %v, error: %v", user, err)
+	}
+
+	cli := New(WithBaseURL(server.URL), WithEnvelope(EnvelopeDecoder{}))
+
+	user, err = PostJSON[genericUser, genericUser](ctx, cli, "/echo", nil, nil, genericUser{Id: 2, Name: "b"})
+	if err != nil || user.Id != 2 || user.Name != "b" {
+		t.Fatalf("user: %v, error: %v", user, err)
+	}
+
+	_, err = GetJSON[genericUser](ctx, cli, "/failed", nil, nil)
+	var ee *EnvelopeError
+	if !errors.As(err, &ee) || ee.Code != "A0001" || ee.Message != "not found" || ee.Status() != http.StatusOK {
+		t.Fatalf("expected envelope error, got %v", err)
+	}
+
+	_, err = GetJSON[genericUser](ctx, cli, "/error", nil, nil)
+	if !errors.As(err, &ee) || ee.Code != "400" || ee.Status() != http.StatusBadRequest {
+		t.Fatalf("expected envelope error, got %v", err)
+	}
+
+	success := EnvelopeDecoder{Success: func(code string) bool { return code == "A0001" }}
+	_, err = GetJSON[genericUser](ctx, New(WithBaseURL(server.URL), WithEnvelope(success)), "/failed", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "missing field [data]") {
+		t.Fatalf("expected missing data error, got %v", err)
+	}
+
+	// 响应格式不符时返回错误而不是零值
+	_, err = GetJSON[genericUser](ctx, cli, "/plain", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "missing field [code]") {
+		t.Fatalf("expected missing code error, got %v", err)
+	}
+
+	data, err := DecodeEnvelope[[]int](EnvelopeDecoder{DataField: "list"}, []byte(`{"code":"0","list":[1,2]}`))
+	if err != nil || len(data) != 2 {
+		t.Fatalf("data: %v, error: %v", data, err)
+	}
+}
+
+func mustJSON(v interface{}) string {
+	bs, _ := json.Marshal(v)
+	return string(bs)
+}
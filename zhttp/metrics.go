@@ -0,0 +1,28 @@
+package zhttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+var (
+	_requestsTotal = zmetrics.NewCounter(
+		"zhttp_client_requests_total",
+		"Total number of outbound HTTP requests.",
+		"host", "method", "status",
+	)
+	_requestDuration = zmetrics.NewHistogram(
+		"zhttp_client_request_duration_seconds",
+		"Outbound HTTP request latency in seconds, until response headers are received.",
+		nil,
+		"host", "method", "status",
+	)
+)
+
+// observeRequest 按目标主机和状态码统计请求数和耗时，请求失败时 status 为 error
+func observeRequest(req *http.Request, status string, start time.Time) {
+	_requestsTotal.Inc(req.URL.Host, req.Method, status)
+	_requestDuration.Observe(time.Since(start).Seconds(), req.URL.Host, req.Method, status)
+}
@@ -0,0 +1,50 @@
+package zhttp
+
+import (
+	"net/http"
+)
+
+// Handler 发送请求并返回响应，与 http.RoundTripper 类似
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware 拦截每次发送的请求，用于认证、签名、日志、重试和指标等，开启重试时每次重试都会经过所有 Middleware
+type Middleware func(next Handler) Handler
+
+// chain 按添加顺序组装 Middleware，先添加的先执行
+func (cli *Client) chain(h Handler) Handler {
+	for i := len(cli.middlewares) - 1; i >= 0; i-- {
+		h = cli.middlewares[i](h)
+	}
+	return h
+}
+
+// RequestMiddleware 在发送请求前调用 f 修改请求
+func RequestMiddleware(f func(req *http.Request)) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			f(req)
+			return next(req)
+		}
+	}
+}
+
+// BasicAuthMiddleware 设置 HTTP Basic 认证
+func BasicAuthMiddleware(username string, password string) Middleware {
+	return RequestMiddleware(func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	})
+}
+
+// BearerTokenMiddleware 设置 Bearer 认证
+func BearerTokenMiddleware(token string) Middleware {
+	return RequestMiddleware(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// HeaderMiddleware 为所有请求设置请求头
+func HeaderMiddleware(key string, value string) Middleware {
+	return RequestMiddleware(func(req *http.Request) {
+		req.Header.Set(key, value)
+	})
+}
@@ -0,0 +1,103 @@
+package zhttp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuth2Config OAuth2 令牌端点的配置
+type OAuth2Config struct {
+	TokenURL     string     // must
+	ClientId     string     // must
+	ClientSecret string     // must
+	Scopes       []string   // optional
+	Params       url.Values // optional, 额外的请求参数，如 audience
+	BasicAuth    bool       // optional, 通过 Basic 认证发送 client_id 和 client_secret，默认放在请求体中
+	Client       *Client    // optional, 请求令牌使用的 Client，默认为 New()
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// ClientCredentials 返回通过 client_credentials 授权获取令牌的 TokenFetcher
+func ClientCredentials(config OAuth2Config) TokenFetcher {
+	return func(ctx context.Context, old *Token) (*Token, error) {
+		return config.fetch(ctx, url.Values{"grant_type": {"client_credentials"}})
+	}
+}
+
+// RefreshToken 返回通过 refresh_token 授权获取令牌的 TokenFetcher，优先使用当前令牌的刷新令牌，没有时使用 refreshToken，
+// 响应中没有新的刷新令牌时继续使用原刷新令牌
+func RefreshToken(config OAuth2Config, refreshToken string) TokenFetcher {
+	return func(ctx context.Context, old *Token) (*Token, error) {
+		current := refreshToken
+		if old != nil && old.RefreshToken != "" {
+			current = old.RefreshToken
+		}
+
+		token, err := config.fetch(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {current}})
+		if err != nil {
+			return nil, err
+		}
+		if token.RefreshToken == "" {
+			token.RefreshToken = current
+		}
+
+		return token, nil
+	}
+}
+
+func (c OAuth2Config) fetch(ctx context.Context, params url.Values) (*Token, error) {
+	cli := c.Client
+	if cli == nil {
+		cli = New()
+	}
+
+	for key, values := range c.Params {
+		params[key] = values
+	}
+	if len(c.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	header := make(http.Header)
+	if c.BasicAuth {
+		auth := url.QueryEscape(c.ClientId) + ":" + url.QueryEscape(c.ClientSecret)
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	} else {
+		params.Set("client_id", c.ClientId)
+		params.Set("client_secret", c.ClientSecret)
+	}
+	header.Set("Accept", "application/json")
+
+	var res tokenResponse
+	err := cli.PostFormCtx(ctx, c.TokenURL, nil, header, params, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s: %s", res.Error, res.ErrorDescription)
+	}
+
+	token := &Token{
+		AccessToken:  res.AccessToken,
+		TokenType:    res.TokenType,
+		RefreshToken: res.RefreshToken,
+	}
+	if res.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
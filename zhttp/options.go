@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/zredact"
 )
 
 type Option func(cli *Client)
@@ -47,6 +49,14 @@ func WithError(error error) Option {
 	}
 }
 
+// WithEnvelope 设置响应的外层结构，参考 EnvelopeDecoder
+func WithEnvelope(decoder EnvelopeDecoder) Option {
+	return func(cli *Client) {
+		d := decoder.Default()
+		cli.envelope = &d
+	}
+}
+
 func WithDumps(enabled bool) Option {
 	return func(cli *Client) {
 		cli.dumps = enabled
@@ -71,26 +81,54 @@ func WithLogEscape(enabled bool) Option {
 	}
 }
 
+// WithRedactor 设置日志脱敏规则，不设置时使用 zredact.Default()，传入空规则的 Redactor 可关闭脱敏
+func WithRedactor(r *zredact.Redactor) Option {
+	return func(cli *Client) {
+		cli.redactor = r
+	}
+}
+
+// WithRetry 请求失败时按 policy 重试，默认只重试幂等方法，开启后无法重放的请求体会被缓存到内存
+func WithRetry(policy RetryPolicy) Option {
+	return func(cli *Client) {
+		p := policy.Default()
+		cli.retry = &p
+	}
+}
+
+// WithBreaker 按 policy 为每个目标主机或 baseURL 创建熔断器，熔断器打开时请求直接返回 *OpenError
+func WithBreaker(policy BreakerPolicy) Option {
+	return func(cli *Client) {
+		cli.breakers = newBreakers(policy)
+	}
+}
+
 func WithRequestBefore(f func(r *http.Request)) Option {
 	return func(cli *Client) {
 		cli.requestBefore = f
 	}
 }
 
-func WithBasicAuth(username string, password string) Option {
+// WithMiddleware 按顺序添加 Middleware，多次调用时追加
+func WithMiddleware(middlewares ...Middleware) Option {
 	return func(cli *Client) {
-		cli.requestBefore = func(req *http.Request) {
-			req.SetBasicAuth(username, password)
-		}
+		cli.middlewares = append(cli.middlewares, middlewares...)
 	}
 }
 
+// WithBasicAuth 添加 BasicAuthMiddleware，不会覆盖 WithRequestBefore 设置的函数
+func WithBasicAuth(username string, password string) Option {
+	return WithMiddleware(BasicAuthMiddleware(username, password))
+}
+
+// WithBearerToken 添加 BearerTokenMiddleware，不会覆盖 WithRequestBefore 设置的函数
 func WithBearerToken(token string) Option {
-	return func(cli *Client) {
-		cli.requestBefore = func(req *http.Request) {
-			req.Header.Set("Authorization", "Bearer "+token)
-		}
-	}
+	return WithMiddleware(BearerTokenMiddleware(token))
+}
+
+// WithTokenSource 添加 TokenMiddleware，为请求设置自动刷新的令牌
+func WithTokenSource(ts *TokenSource) Option {
+	return WithMiddleware(TokenMiddleware(ts))
 }
 
 func WithResponseAfter(f func(res *http.Response) error) Option {
@@ -119,6 +157,10 @@ func Error(error error) Option {
 	return WithError(error)
 }
 
+func Envelope(decoder EnvelopeDecoder) Option {
+	return WithEnvelope(decoder)
+}
+
 func Dumps(enabled bool) Option {
 	return WithDumps(enabled)
 }
@@ -135,6 +177,22 @@ func LogEscape(enabled bool) Option {
 	return WithLogEscape(enabled)
 }
 
+func Redactor(r *zredact.Redactor) Option {
+	return WithRedactor(r)
+}
+
+func Retry(policy RetryPolicy) Option {
+	return WithRetry(policy)
+}
+
+func Breaker(policy BreakerPolicy) Option {
+	return WithBreaker(policy)
+}
+
+func Middlewares(middlewares ...Middleware) Option {
+	return WithMiddleware(middlewares...)
+}
+
 func RequestBefore(f func(r *http.Request)) Option {
 	return WithRequestBefore(f)
 }
@@ -147,6 +205,10 @@ func BearerToken(token string) Option {
 	return WithBearerToken(token)
 }
 
+func Tokens(ts *TokenSource) Option {
+	return WithTokenSource(ts)
+}
+
 func ResponseAfter(f func(res *http.Response) error) Option {
 	return WithResponseAfter(f)
 }
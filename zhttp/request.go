@@ -0,0 +1,218 @@
+package zhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zutil"
+)
+
+// Request 链式构造的请求，如 cli.R().Query("id", "1").JSON(in).Into(&out).Post("/users")
+type Request struct {
+	cli *Client
+	ctx context.Context
+
+	query  url.Values
+	header http.Header
+
+	body     io.Reader
+	bodyLog  []byte
+	mimeType string
+	err      error
+
+	out      interface{}
+	timeout  time.Duration
+	format   string
+	error    error
+	envelope *EnvelopeDecoder
+}
+
+// R 创建 Request
+func (cli *Client) R() *Request {
+	return &Request{
+		cli:    cli,
+		ctx:    context.Background(),
+		query:  make(url.Values),
+		header: make(http.Header),
+	}
+}
+
+func (r *Request) Context(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+func (r *Request) Query(key string, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+func (r *Request) Queries(query url.Values) *Request {
+	for key, values := range query {
+		for _, value := range values {
+			r.query.Add(key, value)
+		}
+	}
+	return r
+}
+
+func (r *Request) Header(key string, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+func (r *Request) Headers(header http.Header) *Request {
+	for key, values := range header {
+		for _, value := range values {
+			r.header.Add(key, value)
+		}
+	}
+	return r
+}
+
+// JSON 将 in 编码为 JSON 作为请求体
+func (r *Request) JSON(in interface{}) *Request {
+	bs, err := json.Marshal(in)
+	if err != nil {
+		r.err = fmt.Errorf("marshal request body error [%v]", err)
+		return r
+	}
+	r.body, r.bodyLog, r.mimeType = bytes.NewReader(bs), bs, "application/json"
+	return r
+}
+
+// Form 将 in 编码为表单作为请求体
+func (r *Request) Form(in url.Values) *Request {
+	encoded := in.Encode()
+	unescaped, _ := url.QueryUnescape(encoded)
+	r.body, r.bodyLog, r.mimeType = strings.NewReader(encoded), []byte(unescaped), "application/x-www-form-urlencoded"
+	return r
+}
+
+// Body 使用 in 作为请求体，请求体不会记录到日志
+func (r *Request) Body(mimeType string, in io.Reader) *Request {
+	r.body, r.bodyLog, r.mimeType = in, nil, mimeType
+	return r
+}
+
+// Into 将响应解析到 out，参考 Client 的 format
+func (r *Request) Into(out interface{}) *Request {
+	r.out = out
+	return r
+}
+
+// Timeout 覆盖 Client 的超时时间
+func (r *Request) Timeout(timeout time.Duration) *Request {
+	r.timeout = timeout
+	return r
+}
+
+// Format 覆盖 Client 的响应报文格式
+func (r *Request) Format(format string) *Request {
+	r.format = format
+	return r
+}
+
+// Error 覆盖 Client 的响应失败时的 JSON 结构，参考 WithError
+func (r *Request) Error(error error) *Request {
+	r.error = error
+	return r
+}
+
+// Envelope 覆盖 Client 的响应外层结构，参考 WithEnvelope
+func (r *Request) Envelope(decoder EnvelopeDecoder) *Request {
+	d := decoder.Default()
+	r.envelope = &d
+	return r
+}
+
+func (r *Request) Get(path string) error {
+	return r.Do(http.MethodGet, path)
+}
+
+func (r *Request) Post(path string) error {
+	return r.Do(http.MethodPost, path)
+}
+
+func (r *Request) Put(path string) error {
+	return r.Do(http.MethodPut, path)
+}
+
+func (r *Request) Patch(path string) error {
+	return r.Do(http.MethodPatch, path)
+}
+
+func (r *Request) Delete(path string) error {
+	return r.Do(http.MethodDelete, path)
+}
+
+// client 返回应用了本次请求覆盖项的 Client 副本
+func (r *Request) client() *Client {
+	if r.timeout == 0 && r.format == "" && r.error == nil && r.envelope == nil {
+		return r.cli
+	}
+
+	cli := *r.cli
+	if r.timeout != 0 {
+		hc := *cli.client
+		hc.Timeout = r.timeout
+		cli.client = &hc
+	}
+	if r.format != "" {
+		cli.format = r.format
+	}
+	if r.error != nil {
+		cli.error = r.error
+	}
+	if r.envelope != nil {
+		cli.envelope = r.envelope
+	}
+
+	return &cli
+}
+
+// Do 发送请求
+func (r *Request) Do(method string, path string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	cli := r.client()
+
+	req, err := cli.newRequest(r.ctx, method, path, r.query, r.header, r.body)
+	if err != nil {
+		return err
+	}
+	if r.mimeType != "" {
+		req.Header.Set("Content-Type", r.mimeType)
+	}
+
+	timer := zutil.NewTimer()
+
+	res, err := cli.doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := cli.handleResponse(res, r.out)
+
+	cli.logHTTP(HTTPLog{
+		Method:       method,
+		Request:      req,
+		RequestBody:  r.bodyLog,
+		Response:     res,
+		ResponseBody: resBody,
+		Error:        err,
+		Cost:         timer.Stops(),
+	})
+
+	return err
+}
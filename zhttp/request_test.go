@@ -0,0 +1,60 @@
+package zhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"id":     r.URL.Query().Get("id"),
+			"name":   in["name"],
+			"auth":   r.Header.Get("Authorization"),
+			"order":  r.Header.Get("X-Order"),
+			"trace":  r.Header.Get("X-Trace"),
+		})
+	}))
+	defer server.Close()
+
+	order := func(name string) Middleware {
+		return RequestMiddleware(func(req *http.Request) {
+			req.Header.Set("X-Order", req.Header.Get("X-Order")+name)
+		})
+	}
+	cli := New(
+		WithBaseURL(server.URL),
+		WithBearerToken("token"),
+		WithRequestBefore(func(r *http.Request) { r.Header.Set("X-Trace", "1") }),
+		WithMiddleware(order("a"), order("b")),
+		WithFormat(FormatText),
+	)
+
+	var out map[string]string
+	err := cli.R().
+		Query("id", "7").
+		JSON(map[string]string{"name": "tom"}).
+		Format(FormatJSON).
+		Timeout(time.Second).
+		Into(&out).
+		Post("/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"method": "POST", "id": "7", "name": "tom", "auth": "Bearer token", "order": "ab", "trace": "1"}
+	for key, value := range want {
+		if out[key] != value {
+			t.Errorf("%s: got %q, want %q", key, out[key], value)
+		}
+	}
+	if cli.format != FormatText {
+		t.Error("request override changed client format")
+	}
+}
@@ -0,0 +1,212 @@
+package zhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var (
+	// DefaultRetryStatuses 默认重试的响应状态码
+	DefaultRetryStatuses = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	// DefaultRetryMethods 默认重试的请求方法，只包含幂等方法
+	DefaultRetryMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodPut,
+		http.MethodDelete,
+		http.MethodTrace,
+	}
+)
+
+// RetryPolicy 请求失败时的重试策略，第 n 次重试前等待 MinBackoff*2^(n-1)，并在 ±Jitter 比例内随机浮动
+type RetryPolicy struct {
+	MaxAttempts int           // optional, 最大请求次数，包括第一次请求，默认为 3
+	MinBackoff  time.Duration // optional, 第一次重试前的等待时间，默认为 100ms
+	MaxBackoff  time.Duration // optional, 重试前的最长等待时间，响应的 Retry-After 超出该值时不再重试，默认为 5s
+	Jitter      float64       // optional, 等待时间的随机浮动比例，取值 0～1，默认为 0.2
+	Statuses    []int         // optional, 重试的响应状态码，默认为 DefaultRetryStatuses
+	Methods     []string      // optional, 重试的请求方法，默认为 DefaultRetryMethods
+	// optional, 自定义是否重试，设置后替代网络错误和 Statuses 的判断，err 不为 nil 时 res 为 nil
+	Retryable func(res *http.Response, err error) bool
+}
+
+func (p RetryPolicy) Default() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.Jitter <= 0 || p.Jitter > 1 {
+		p.Jitter = 0.2
+	}
+	if len(p.Statuses) == 0 {
+		p.Statuses = DefaultRetryStatuses
+	}
+	if len(p.Methods) == 0 {
+		p.Methods = DefaultRetryMethods
+	}
+	return p
+}
+
+func (p *RetryPolicy) allowMethod(method string) bool {
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) shouldRetry(res *http.Response, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(res, err)
+	}
+	if err != nil {
+		return isNetworkError(err)
+	}
+	for _, status := range p.Statuses {
+		if res.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 返回第 n 次重试前的等待时间
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := p.MinBackoff << (n - 1)
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	d = time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// isNetworkError 是否为连接失败、超时、连接被重置等可以通过重试恢复的网络错误，
+// client.Do 返回的错误都是实现了 net.Error 的 *url.Error，需要判断其包装的错误
+func isNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+
+	// TLS 握手失败和证书错误重试也不会成功
+	if isTLSError(err) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	var oe *net.OpError
+	return errors.As(err, &oe) && (oe.Op == "dial" || oe.Op == "read")
+}
+
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+func retryAfter(res *http.Response) time.Duration {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// replayable 开启重试时缓存无法重放的请求体，使请求可以重复发送
+func (cli *Client) replayable(req *http.Request) error {
+	if cli.retry == nil || req.Body == nil || req.Body == http.NoBody || req.GetBody != nil || !cli.retry.allowMethod(req.Method) {
+		return nil
+	}
+
+	bs, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(bs))
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bs)), nil
+	}
+
+	return nil
+}
+
+type attemptKey struct{}
+
+// attempt 返回请求是第几次发送，没有开启重试时返回 0
+func attempt(req *http.Request, res *http.Response) int {
+	if res != nil && res.Request != nil {
+		req = res.Request
+	}
+	n, _ := req.Context().Value(attemptKey{}).(int)
+	return n
+}
+
+// sleepContext 等待 d，ctx 结束时提前返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
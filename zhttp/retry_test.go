@@ -0,0 +1,139 @@
+package zhttp
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	var logs strings.Builder
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+
+	cli := New(WithBaseURL(server.URL), WithFormat(FormatText), WithLogger(logger), WithRetry(RetryPolicy{
+		MinBackoff: time.Millisecond,
+		Methods:    []string{http.MethodPut, http.MethodPost},
+	}))
+
+	var out []byte
+	err := cli.PostBinary("/echo", nil, nil, "text/plain", strings.NewReader("hello"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || string(out) != "hello" {
+		t.Errorf("calls: %d, body: %s", calls.Load(), out)
+	}
+	if !strings.Contains(logs.String(), "attempt: 2") || !strings.Contains(logs.String(), "Request succeed(200)") {
+		t.Errorf("unexpected logs: %s", logs.String())
+	}
+}
+
+func TestRetryIdempotentOnly(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cli := New(WithBaseURL(server.URL), WithRetry(RetryPolicy{MinBackoff: time.Millisecond}))
+
+	_ = cli.Post("/", nil, nil, nil, nil)
+	if calls.Load() != 1 {
+		t.Errorf("post calls: %d, want 1", calls.Load())
+	}
+
+	calls.Store(0)
+	err := cli.Get("/", nil, nil, nil)
+	if err == nil || calls.Load() != 3 {
+		t.Errorf("get calls: %d, error: %v", calls.Load(), err)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cli := New(WithBaseURL(server.URL), WithRetry(RetryPolicy{}))
+
+	_ = cli.Get("/", nil, nil, nil)
+	if calls.Load() != 1 {
+		t.Errorf("calls: %d, want 1", calls.Load())
+	}
+}
+
+func TestIsNetworkError(t *testing.T) {
+	do := func(url string) error {
+		res, err := http.Get(url)
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		return err
+	}
+
+	// 连接被拒绝
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	_ = ln.Close()
+	if err = do(refused); !isNetworkError(err) {
+		t.Errorf("connection refused should be retried: %v", err)
+	}
+
+	// 超时
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	_, err = (&http.Client{Timeout: 10 * time.Millisecond}).Get(slow.URL)
+	if !isNetworkError(err) {
+		t.Errorf("timeout should be retried: %v", err)
+	}
+
+	// 不支持的协议
+	if err = do("ftp://127.0.0.1/"); err == nil || isNetworkError(err) {
+		t.Errorf("unsupported scheme should not be retried: %v", err)
+	}
+
+	// 证书不受信任
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	if err = do(tlsServer.URL); err == nil || isNetworkError(err) {
+		t.Errorf("certificate error should not be retried: %v", err)
+	}
+
+	if isNetworkError(context.Canceled) {
+		t.Error("canceled should not be retried")
+	}
+}
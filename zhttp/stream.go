@@ -0,0 +1,450 @@
+package zhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zutil"
+)
+
+// ErrStopStream 流式读取的回调返回该错误时停止读取，并且不作为错误返回
+var ErrStopStream = errors.New("stop stream")
+
+// maxErrorBody 读取失败响应的响应体的最大长度
+const maxErrorBody = 64 * 1024
+
+// multipartBody 通过 io.Pipe 流式生成 multipart 请求体，write 返回的错误会作为读取请求体的错误
+func multipartBody(write func(writer *multipart.Writer) error) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := write(writer)
+		if err == nil {
+			err = writer.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType()
+}
+
+// streaming 返回不限制请求总时长的 Client 副本，http.Client 的 Timeout 包括读取响应体的时间，
+// 会中断长时间的流式读取和上传，流式请求只由 ctx 限制
+func (cli *Client) streaming() *Client {
+	if cli.client.Timeout == 0 {
+		return cli
+	}
+
+	hc := *cli.client
+	hc.Timeout = 0
+
+	c := *cli
+	c.client = &hc
+
+	return &c
+}
+
+type stream struct {
+	cli    *Client
+	req    *http.Request
+	res    *http.Response
+	timer  zutil.Timer
+	body   []byte
+	cancel context.CancelCauseFunc
+}
+
+// errHeaderTimeout 流式请求建立连接或等待响应头超时时取消请求的原因，熔断器据此将其计为失败
+var errHeaderTimeout = errors.New("wait response header timeout")
+
+// doStreaming 使用不限制总时长的 Client 发送请求，Client 的超时时间分别限制建立连接和发送完请求体后等待响应头的时间，
+// 上传请求体和读取响应体只由 ctx 限制。req 的 ctx 必须由 cancel 取消，超时时以 errHeaderTimeout 为原因取消请求
+func (cli *Client) doStreaming(req *http.Request, cancel context.CancelCauseFunc) (*http.Response, error) {
+	timeout := cli.client.Timeout
+	if timeout <= 0 {
+		return cli.doRequest(req)
+	}
+
+	timer := time.AfterFunc(timeout, func() { cancel(errHeaderTimeout) })
+	defer timer.Stop()
+
+	trace := &httptrace.ClientTrace{
+		GotConn:      func(httptrace.GotConnInfo) { timer.Stop() },
+		WroteRequest: func(httptrace.WroteRequestInfo) { timer.Reset(timeout) },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	res, err := cli.streaming().doRequest(req)
+	if errors.Is(context.Cause(req.Context()), errHeaderTimeout) {
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		return nil, fmt.Errorf("%v after %s", errHeaderTimeout, timeout)
+	}
+
+	return res, err
+}
+
+// openStream 发送请求并返回未读取的响应，in 不为 nil 时编码为 JSON 作为请求体，响应状态码不是 2xx 时返回 *ResponseError，
+// 成功时需要调用 close 关闭响应并记录日志。Client 的超时时间只限制建立连接和等待响应头，读取响应体只由 ctx 限制
+func (cli *Client) openStream(ctx context.Context, method string, path string, query url.Values, header http.Header, in interface{}) (*stream, error) {
+	var (
+		body    io.Reader
+		reqBody []byte
+	)
+	if in != nil {
+		bs, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request body error [%v]", err)
+		}
+		body, reqBody = bytes.NewReader(bs), bs
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	req, err := cli.newRequest(ctx, method, path, query, header, body)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	s := &stream{cli: cli, req: req, timer: zutil.NewTimer(), body: reqBody, cancel: cancel}
+
+	res, err := cli.doStreaming(req, cancel)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	s.res = res
+
+	if res.StatusCode/100 != 2 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		err = newResponseError(res.StatusCode, string(resBody))
+		s.close(err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// close 关闭响应并记录日志，流式读取的响应体不记录到日志
+func (s *stream) close(err error) {
+	_ = s.res.Body.Close()
+	s.cancel(nil)
+	s.cli.logHTTP(HTTPLog{
+		Method:       s.req.Method,
+		Request:      s.req,
+		RequestBody:  s.body,
+		Response:     s.res,
+		ResponseBody: nil,
+		Error:        err,
+		Cost:         s.timer.Stops(),
+	})
+}
+
+// Event Server-Sent Events 事件
+type Event struct {
+	Id    string
+	Event string
+	Data  string
+	Retry int
+}
+
+// SSE 以 GET 请求读取 Server-Sent Events，每收到一个事件调用一次 handler，handler 返回错误时停止读取
+func (cli *Client) SSE(ctx context.Context, path string, query url.Values, header http.Header, handler func(event Event) error) error {
+	s, err := cli.openStream(ctx, http.MethodGet, path, query, withHeader(header, "Accept", "text/event-stream"), nil)
+	if err != nil {
+		return err
+	}
+
+	err = readEvents(s.res.Body, handler)
+	if errors.Is(err, ErrStopStream) {
+		err = nil
+	}
+	s.close(err)
+
+	return err
+}
+
+// readEvents 按 https://html.spec.whatwg.org/multipage/server-sent-events.html 解析事件
+func readEvents(r io.Reader, handler func(event Event) error) error {
+	var (
+		reader = bufio.NewReader(r)
+		event  Event
+		data   strings.Builder
+		filled bool
+	)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read event error [%v]", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if filled {
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				err = handler(event)
+				if err != nil {
+					return err
+				}
+			}
+			event, filled = Event{Id: event.Id}, false
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			filled = true
+		case "id":
+			event.Id = value
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				event.Retry = n
+			}
+		}
+	}
+}
+
+// StreamNDJSON 逐行将 NDJSON 响应解码为 T 并写入返回的通道，in 不为 nil 时编码为 JSON 作为请求体，
+// 通道关闭后可从错误通道读取结束原因，正常结束时为 nil。ctx 结束时停止读取并关闭响应，
+// 提前停止读取通道时必须取消 ctx，否则解码的 goroutine 会一直阻塞
+func StreamNDJSON[T any](ctx context.Context, cli *Client, method string, path string, query url.Values, header http.Header, in interface{}) (<-chan T, <-chan error) {
+	var (
+		ch    = make(chan T)
+		errCh = make(chan error, 1)
+	)
+
+	s, err := cli.openStream(ctx, method, path, query, withHeader(header, "Accept", "application/x-ndjson"), in)
+	if err != nil {
+		close(ch)
+		errCh <- err
+		return ch, errCh
+	}
+
+	go func() {
+		defer close(ch)
+
+		err := decodeLines(s.res.Body, func(line []byte) error {
+			var v T
+			err := json.Unmarshal(line, &v)
+			if err != nil {
+				return fmt.Errorf("unmarshal line error [%v]", err)
+			}
+			select {
+			case ch <- v:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		s.close(err)
+
+		errCh <- err
+	}()
+
+	return ch, errCh
+}
+
+// decodeLines 逐行读取 r，跳过空行
+func decodeLines(r io.Reader, handle func(line []byte) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read line error [%v]", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		err = handle(line)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DownloadOptions 下载文件的选项
+type DownloadOptions struct {
+	Resume   bool                             // optional, 文件未下载完成时通过 Range 请求继续下载，未完成的文件保存在 <file>.part
+	Progress func(written int64, total int64) // optional, 每写入一段数据调用一次，total 未知时为 -1
+	Checksum string                           // optional, 文件的十六进制摘要，不一致时删除文件并返回错误
+	Hash     func() hash.Hash                 // optional, 摘要算法，默认为 sha256
+}
+
+// Download 以 GET 请求将响应体流式写入 file
+func (cli *Client) Download(ctx context.Context, path string, query url.Values, header http.Header, file string, options DownloadOptions) error {
+	if options.Hash == nil {
+		options.Hash = sha256.New
+	}
+
+	part := file + ".part"
+
+	var offset int64
+	if options.Resume {
+		if info, err := os.Stat(part); err == nil {
+			offset = info.Size()
+		}
+	}
+	if offset > 0 {
+		header = withHeader(header, "Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	s, err := cli.openStream(ctx, http.MethodGet, path, query, header, nil)
+	if err != nil {
+		// 未完成的文件实际已经下载完成
+		var re *ResponseError
+		if offset > 0 && errors.As(err, &re) && re.Status() == http.StatusRequestedRangeNotSatisfiable {
+			return finishDownload(part, file, options)
+		}
+		return err
+	}
+
+	err = writeDownload(s.res, part, offset, options)
+	s.close(err)
+	if err != nil {
+		return err
+	}
+
+	return finishDownload(part, file, options)
+}
+
+func writeDownload(res *http.Response, part string, offset int64, options DownloadOptions) error {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	total := res.ContentLength
+	if res.StatusCode == http.StatusPartialContent && offset > 0 {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		total = contentRangeTotal(res.Header.Get("Content-Range"))
+	} else {
+		offset = 0
+	}
+
+	f, err := os.OpenFile(part, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("open file error [%v]", err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	if options.Progress != nil {
+		w = &progressWriter{w: f, written: offset, total: total, progress: options.Progress}
+	}
+
+	_, err = io.Copy(w, res.Body)
+	if err != nil {
+		return fmt.Errorf("write file error [%v]", err)
+	}
+
+	return f.Close()
+}
+
+// finishDownload 校验摘要后将未完成的文件重命名为 file
+func finishDownload(part string, file string, options DownloadOptions) error {
+	if options.Checksum != "" {
+		sum, err := fileChecksum(part, options.Hash())
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, options.Checksum) {
+			_ = os.Remove(part)
+			return fmt.Errorf("checksum mismatch, expected %s, got %s", options.Checksum, sum)
+		}
+	}
+
+	err := os.Rename(part, file)
+	if err != nil {
+		return fmt.Errorf("rename file error [%v]", err)
+	}
+
+	return nil
+}
+
+func fileChecksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file error [%v]", err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", fmt.Errorf("read file error [%v]", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentRangeTotal 解析 Content-Range 中的总长度，如 bytes 100-199/200，未知时返回 -1
+func contentRangeTotal(value string) int64 {
+	_, total, ok := strings.Cut(value, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written int64, total int64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	pw.progress(pw.written, pw.total)
+	return n, err
+}
+
+// withHeader 返回设置了 key 的 header 副本
+func withHeader(header http.Header, key string, value string) http.Header {
+	result := header.Clone()
+	if result == nil {
+		result = make(http.Header)
+	}
+	result.Set(key, value)
+	return result
+}
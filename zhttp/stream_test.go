@@ -0,0 +1,232 @@
+package zhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, ": comment\nid: 1\nevent: greet\ndata: hello\ndata: world\n\ndata: second\r\n\r\ndata: ignored\n\n")
+	}))
+	defer server.Close()
+
+	var events []Event
+	err := New().SSE(context.Background(), server.URL, nil, nil, func(event Event) error {
+		events = append(events, event)
+		if len(events) == 2 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events: %+v", events)
+	}
+	if events[0] != (Event{Id: "1", Event: "greet", Data: "hello\nworld"}) || events[1] != (Event{Id: "1", Data: "second"}) {
+		t.Errorf("events: %+v", events)
+	}
+}
+
+func TestSSEOutlivesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 5; i++ {
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	cli := New(WithBaseURL(server.URL), WithTimeout(100*time.Millisecond))
+
+	var n int
+	err := cli.SSE(context.Background(), "/", nil, nil, func(event Event) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 5 {
+		t.Fatalf("events: %d, error: %v", n, err)
+	}
+
+	// 超时时间仍然限制等待响应头
+	err = cli.SSE(context.Background(), "/slow", nil, nil, func(event Event) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected header timeout, got %v", err)
+	}
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(w, "{\"n\":%d}\n\n", i)
+		}
+	}))
+	defer server.Close()
+
+	type item struct {
+		N int `json:"n"`
+	}
+
+	ch, errCh := StreamNDJSON[item](context.Background(), New(), http.MethodPost, server.URL, nil, nil, map[string]int{"limit": 3})
+
+	var sum int
+	for v := range ch {
+		sum += v.N
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if sum != 3 {
+		t.Errorf("sum: %d", sum)
+	}
+
+	// 提前停止读取时取消 ctx，解码的 goroutine 退出
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, errCh = StreamNDJSON[item](ctx, New(), http.MethodPost, server.URL, nil, nil, nil)
+	<-ch
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("expected canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decode goroutine did not exit")
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	var (
+		dir  = t.TempDir()
+		file = filepath.Join(dir, "data.txt")
+		sum  = sha256.Sum256([]byte(content))
+	)
+	err := os.WriteFile(file+".part", []byte(content[:4000]), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written, total int64
+	err = New().Download(context.Background(), server.URL, nil, nil, file, DownloadOptions{
+		Resume:   true,
+		Checksum: hex.EncodeToString(sum[:]),
+		Progress: func(w int64, t int64) { written, total = w, t },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, _ := os.ReadFile(file)
+	if string(bs) != content {
+		t.Errorf("downloaded %d bytes, want %d", len(bs), len(content))
+	}
+	if written != int64(len(content)) || total != int64(len(content)) {
+		t.Errorf("progress: %d/%d", written, total)
+	}
+
+	err = New().Download(context.Background(), server.URL, nil, nil, file, DownloadOptions{Checksum: "00"})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestPostFormDataPipe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bs, _ := io.ReadAll(file)
+		_, _ = fmt.Fprintf(w, "%s:%s", r.FormValue("name"), bs)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	_ = os.WriteFile(path, []byte("hello"), 0644)
+
+	var out []byte
+	cli := New(WithFormat(FormatText))
+	err := cli.PostFormData(server.URL, nil, nil, map[string]string{"name": "tom"}, map[string]string{"file": path}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "tom:hello" {
+		t.Errorf("response: %s", out)
+	}
+
+	err = cli.PostFormData(server.URL, nil, nil, nil, map[string]string{"file": path + ".missing"}, &out)
+	if err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+type slowReader struct {
+	n int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(40 * time.Millisecond)
+	r.n--
+	return copy(p, "chunk"), nil
+}
+
+func TestUploadOutlivesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = fmt.Fprint(w, len(bs))
+	}))
+	defer server.Close()
+
+	cli := New(WithBaseURL(server.URL), WithFormat(FormatText), WithTimeout(100*time.Millisecond))
+
+	var out []byte
+	err := cli.PostBinary("/", nil, nil, "text/plain", &slowReader{n: 5}, &out)
+	if err != nil || string(out) != "25" {
+		t.Fatalf("response: %s, error: %v", out, err)
+	}
+
+	err = cli.PostStream("/", nil, nil, nil, "file", "a.txt", &slowReader{n: 5}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 上传完成后仍然限制等待响应头的时间
+	path := filepath.Join(t.TempDir(), "a.txt")
+	_ = os.WriteFile(path, []byte("hello"), 0644)
+	err = cli.PostFormData("/slow", nil, nil, nil, map[string]string{"file": path}, &out)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected header timeout, got %v", err)
+	}
+}
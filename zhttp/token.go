@@ -0,0 +1,320 @@
+package zhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Token 访问令牌
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"` // 为空时使用 Bearer
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"` // 零值表示不过期
+}
+
+// Type 返回令牌类型，默认为 Bearer
+func (t *Token) Type() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer"
+	}
+	return t.TokenType
+}
+
+// SetAuthHeader 设置请求的 Authorization 头
+func (t *Token) SetAuthHeader(req *http.Request) {
+	req.Header.Set("Authorization", t.Type()+" "+t.AccessToken)
+}
+
+// Valid 判断令牌是否未过期
+func (t *Token) Valid() bool {
+	return t.fresh(0)
+}
+
+// fresh 判断令牌在 early 之后是否仍未过期
+func (t *Token) fresh(early time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > early)
+}
+
+// TokenFetcher 获取新的令牌，old 为当前的令牌，没有时为 nil
+type TokenFetcher func(ctx context.Context, old *Token) (*Token, error)
+
+// TokenCache 令牌的共享缓存，用于多个实例共享令牌，值为 JSON 编码的 Token，如 zredis.Cache
+type TokenCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)                        // 不存在时返回 nil, nil
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error // ttl 为 0 时不过期
+}
+
+// ErrTokenCacheKey 设置了 TokenConfig.Cache 但没有设置 Key
+var ErrTokenCacheKey = errors.New("token cache key is required")
+
+type TokenConfig struct {
+	Key          string         // optional, 令牌在 Cache 中的键，设置 Cache 时必须设置
+	Cache        TokenCache     // optional, 共享缓存，刷新令牌前先从缓存读取，获取新令牌后写入缓存
+	RefreshEarly time.Duration  // optional, 在令牌过期前多久在后台主动刷新，默认为 1m
+	Timeout      time.Duration  // optional, 刷新令牌的超时时间，默认为 30s
+	Logger       *logrus.Logger // optional, 记录刷新和缓存失败的日志
+}
+
+func (c TokenConfig) Default() TokenConfig {
+	if c.RefreshEarly <= 0 {
+		c.RefreshEarly = time.Minute
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	return c
+}
+
+// TokenSource 在内存中缓存令牌，在令牌过期前 RefreshEarly 时在后台主动刷新，并发刷新时只有一个请求调用 TokenFetcher
+type TokenSource struct {
+	fetch  TokenFetcher
+	config TokenConfig
+
+	mu     sync.Mutex
+	token  *Token
+	call   *tokenCall
+	timer  *time.Timer
+	closed bool
+}
+
+// tokenCall 一次进行中的刷新，并发刷新的请求等待同一个 tokenCall
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+func NewTokenSource(fetch TokenFetcher, config TokenConfig) (*TokenSource, error) {
+	if config.Cache != nil && config.Key == "" {
+		return nil, ErrTokenCacheKey
+	}
+
+	return &TokenSource{
+		fetch:  fetch,
+		config: config.Default(),
+	}, nil
+}
+
+// Close 停止后台刷新
+func (ts *TokenSource) Close() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.closed = true
+	if ts.timer != nil {
+		ts.timer.Stop()
+		ts.timer = nil
+	}
+}
+
+// Token 返回令牌，令牌即将过期时刷新，刷新失败但令牌未过期时仍返回当前令牌
+func (ts *TokenSource) Token(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	token := ts.token
+	ts.mu.Unlock()
+
+	if token.fresh(ts.config.RefreshEarly) {
+		return token, nil
+	}
+
+	newToken, err := ts.refresh(ctx, "")
+	if err != nil {
+		if token.Valid() {
+			ts.logWarn("Refresh token failed, use current token, error: %v.", err)
+			return token, nil
+		}
+		return nil, err
+	}
+
+	return newToken, nil
+}
+
+// Invalidate 丢弃当前令牌并获取新的令牌，用于服务端拒绝令牌时，bad 为被拒绝的访问令牌
+func (ts *TokenSource) Invalidate(ctx context.Context, bad string) (*Token, error) {
+	return ts.refresh(ctx, bad)
+}
+
+// refresh 获取新的令牌，bad 不为空时不再使用该访问令牌，当前令牌已经不是 bad 时直接返回当前令牌
+func (ts *TokenSource) refresh(ctx context.Context, bad string) (*Token, error) {
+	ts.mu.Lock()
+	if bad != "" && ts.token != nil && ts.token.AccessToken != bad {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+	call := ts.call
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		ts.call = call
+		// 刷新不受单个请求的 ctx 影响，避免一个请求取消导致其他等待的请求失败
+		go ts.doRefresh(call, ts.token, bad)
+	}
+	ts.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (ts *TokenSource) doRefresh(call *tokenCall, old *Token, bad string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.config.Timeout)
+	defer cancel()
+
+	call.token, call.err = ts.load(ctx, old, bad)
+
+	ts.mu.Lock()
+	if call.err == nil {
+		ts.token = call.token
+		ts.schedule(call.token)
+	}
+	ts.call = nil
+	ts.mu.Unlock()
+
+	close(call.done)
+}
+
+// schedule 在令牌过期前 RefreshEarly 时在后台刷新，刷新失败时由之后的 Token 调用重试，调用前必须加锁
+func (ts *TokenSource) schedule(token *Token) {
+	if ts.timer != nil {
+		ts.timer.Stop()
+		ts.timer = nil
+	}
+	if ts.closed || token.Expiry.IsZero() {
+		return
+	}
+
+	d := time.Until(token.Expiry) - ts.config.RefreshEarly
+	if d <= 0 {
+		return
+	}
+	ts.timer = time.AfterFunc(d, func() {
+		_, err := ts.refresh(context.Background(), "")
+		if err != nil {
+			ts.logWarn("Refresh token in background failed, error: %v.", err)
+		}
+	})
+}
+
+// load 优先使用共享缓存中的令牌，缓存中没有可用的令牌时调用 TokenFetcher 并写入缓存
+func (ts *TokenSource) load(ctx context.Context, old *Token, bad string) (*Token, error) {
+	if ts.config.Cache != nil {
+		cached, err := ts.getCache(ctx)
+		if err != nil {
+			ts.logWarn("Get token from cache failed, key: %s, error: %v.", ts.config.Key, err)
+		} else if cached != nil {
+			if cached.AccessToken != bad && cached.fresh(ts.config.RefreshEarly) {
+				return cached, nil
+			}
+			// 其他实例可能已经轮换了刷新令牌
+			old = cached
+		}
+	}
+
+	token, err := ts.fetch(ctx, old)
+	if err != nil {
+		return nil, fmt.Errorf("fetch token error [%v]", err)
+	}
+	if token == nil || token.AccessToken == "" {
+		return nil, fmt.Errorf("fetch token error [empty access token]")
+	}
+
+	if ts.config.Cache != nil {
+		err = ts.setCache(ctx, token)
+		if err != nil {
+			ts.logWarn("Set token to cache failed, key: %s, error: %v.", ts.config.Key, err)
+		}
+	}
+
+	return token, nil
+}
+
+func (ts *TokenSource) getCache(ctx context.Context) (*Token, error) {
+	bs, err := ts.config.Cache.Get(ctx, ts.config.Key)
+	if err != nil || bs == nil {
+		return nil, err
+	}
+
+	var token Token
+	err = json.Unmarshal(bs, &token)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal token error [%v]", err)
+	}
+
+	return &token, nil
+}
+
+// setCache 令牌过期时缓存同时过期，令牌不过期时缓存不过期
+func (ts *TokenSource) setCache(ctx context.Context, token *Token) error {
+	var ttl time.Duration
+	if !token.Expiry.IsZero() {
+		ttl = time.Until(token.Expiry)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+
+	bs, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("marshal token error [%v]", err)
+	}
+
+	return ts.config.Cache.Set(ctx, ts.config.Key, bs, ttl)
+}
+
+func (ts *TokenSource) logWarn(format string, args ...interface{}) {
+	if ts.config.Logger != nil {
+		ts.config.Logger.Warnf(format, args...)
+	}
+}
+
+// TokenMiddleware 为请求设置 ts 的令牌，响应 401 时刷新令牌并重试一次，请求体不能重放时不重试
+func TokenMiddleware(ts *TokenSource) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			token, err := ts.Token(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("get token error [%v]", err)
+			}
+			token.SetAuthHeader(req)
+
+			res, err := next(req)
+			if err != nil || res.StatusCode != http.StatusUnauthorized {
+				return res, err
+			}
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return res, nil
+			}
+
+			newToken, err := ts.Invalidate(req.Context(), token.AccessToken)
+			if err != nil {
+				return res, nil
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return res, nil
+				}
+				req.Body = body
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBody))
+			_ = res.Body.Close()
+
+			newToken.SetAuthHeader(req)
+
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package zhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenSource(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		n := issued.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"t` + strconv.Itoa(int(n)) + `","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	// 只接受最新的令牌
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t"+strconv.Itoa(int(issued.Load())) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ts, err := NewTokenSource(ClientCredentials(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientId:     "id",
+		ClientSecret: "secret",
+	}), TokenConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	cli := New(WithBaseURL(server.URL), WithTokenSource(ts))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cli.Get("/", nil, nil, nil)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if issued.Load() != 1 {
+		t.Fatalf("issued: %d, want 1", issued.Load())
+	}
+
+	// 服务端拒绝令牌时刷新并重试
+	issued.Add(1)
+	err = cli.PostJSON("/", nil, nil, map[string]string{"a": "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := ts.Token(context.Background())
+	if token.AccessToken != "t3" {
+		t.Errorf("token: %s, want t3", token.AccessToken)
+	}
+
+	// 即将过期时主动刷新
+	ts.mu.Lock()
+	ts.token.Expiry = time.Now().Add(30 * time.Second)
+	ts.mu.Unlock()
+	token, _ = ts.Token(context.Background())
+	if token.AccessToken != "t4" {
+		t.Errorf("token: %s, want t4", token.AccessToken)
+	}
+}
+
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func TestTokenSourceBackground(t *testing.T) {
+	cache := &memoryCache{data: make(map[string][]byte)}
+
+	_, err := NewTokenSource(nil, TokenConfig{Cache: cache})
+	if err != ErrTokenCacheKey {
+		t.Fatalf("expected key error, got %v", err)
+	}
+
+	var fetched atomic.Int32
+	fetch := func(ctx context.Context, old *Token) (*Token, error) {
+		n := fetched.Add(1)
+		expiry := time.Now().Add(time.Hour)
+		if n == 1 {
+			expiry = time.Now().Add(time.Second + 100*time.Millisecond)
+		}
+		return &Token{AccessToken: "t" + strconv.Itoa(int(n)), Expiry: expiry}, nil
+	}
+	ts, err := NewTokenSource(fetch, TokenConfig{Key: "k", Cache: cache, RefreshEarly: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	token, err := ts.Token(context.Background())
+	if err != nil || token.AccessToken != "t1" {
+		t.Fatalf("token: %v, error: %v", token, err)
+	}
+
+	// 过期前 RefreshEarly 时在后台刷新，不需要等待下一次请求
+	time.Sleep(300 * time.Millisecond)
+	if fetched.Load() != 2 {
+		t.Fatalf("fetched: %d, want 2", fetched.Load())
+	}
+	cached, _ := cache.Get(context.Background(), "k")
+	if !strings.Contains(string(cached), `"access_token":"t2"`) {
+		t.Errorf("cache: %s", cached)
+	}
+}
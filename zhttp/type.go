@@ -5,6 +5,9 @@ import (
 	"net/http"
 )
 
+// JsonResponse 响应成功时 out 实现该接口且 Failed 返回 true 时视为失败
+//
+// Deprecated: 使用 WithEnvelope 设置响应的外层结构和成功条件
 type JsonResponse interface {
 	Failed() bool
 }
@@ -1,9 +1,15 @@
 package zkafka
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
+	"github.com/yyliziqiu/zlib/ztrace"
 )
 
 func NewConsumer(config Config) (*kafka.Consumer, error) {
@@ -20,18 +26,39 @@ func NewConsumer(config Config) (*kafka.Consumer, error) {
 	return consumer, nil
 }
 
-// func consume(consumer *kafka.Consumer) {
-// 	for {
-// 		select {
-// 		case <-quit:
-// 			log.Info("[runConsumeKafkaMessage] Quit.")
-// 			return
-// 		default:
-// 			msg, err := consumer.ReadMessage(-1)
-// 			if err != nil {
-// 				continue
-// 			}
-// 			// to do something
-// 		}
-// 	}
-// }
+// Consume 循环读取消息并交给 handle 处理，直到 ctx 取消或发生致命错误，读取超时以外的错误会记录日志
+func Consume(ctx context.Context, name string, consumer *kafka.Consumer, handle func(*kafka.Message)) {
+	ConsumeWithContext(ctx, name, consumer, func(_ context.Context, msg *kafka.Message) {
+		handle(msg)
+	})
+}
+
+// ConsumeWithContext 与 Consume 相同，handle 的 ctx 中包含以消息头中上游 SpanContext 为父 span 的 consumer span
+func ConsumeWithContext(ctx context.Context, name string, consumer *kafka.Consumer, handle func(context.Context, *kafka.Message)) {
+	done := zshutdown.Add("kafka-consumer-" + name)
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := consumer.ReadMessage(100 * time.Millisecond)
+			if err != nil {
+				kerr, ok := err.(kafka.Error)
+				if ok && kerr.Code() == kafka.ErrTimedOut {
+					continue
+				}
+				if ok && kerr.IsFatal() {
+					zlog.Errorf("Kafka consumer %s exit, fatal error: %v.", name, err)
+					return
+				}
+				zlog.Warnf("Kafka consumer %s read message failed, error: %v.", name, err)
+				continue
+			}
+			mctx, span := startSpan(MessageContext(ctx, msg), msg, "receive", ztrace.SpanKindConsumer)
+			handle(mctx, msg)
+			span.End()
+		}
+	}
+}
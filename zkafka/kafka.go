@@ -1,9 +1,13 @@
 package zkafka
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/yyliziqiu/zlib/zhealth"
 )
 
 var (
@@ -33,6 +37,7 @@ func Init(configs ...Config) error {
 				return err
 			}
 			_consumers[config.Id] = consumer
+			registerProbe(config, consumer)
 		case RoleProducer:
 			producer, err := NewProducer(config)
 			if err != nil {
@@ -40,6 +45,7 @@ func Init(configs ...Config) error {
 				return err
 			}
 			_producers[config.Id] = producer
+			registerProbe(config, producer)
 		default:
 			return errors.New("not support kafka role")
 		}
@@ -48,11 +54,33 @@ func Init(configs ...Config) error {
 	return nil
 }
 
+// metadataGetter 由 *kafka.Consumer 和 *kafka.Producer 实现
+type metadataGetter interface {
+	GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error)
+}
+
+func registerProbe(config Config, getter metadataGetter) {
+	zhealth.Register(zhealth.Probe{
+		Name:  "kafka." + config.Id,
+		Level: config.HealthLevel,
+		Check: func(ctx context.Context) error {
+			timeout := zhealth.DefaultTimeout
+			if deadline, ok := ctx.Deadline(); ok {
+				timeout = time.Until(deadline)
+			}
+			_, err := getter.GetMetadata(nil, false, int(timeout.Milliseconds()))
+			return err
+		},
+	})
+}
+
 func Finally() {
-	for _, consumer := range _consumers {
+	for id, consumer := range _consumers {
+		zhealth.Unregister("kafka." + id)
 		_ = consumer.Close()
 	}
-	for _, producer := range _producers {
+	for id, producer := range _producers {
+		zhealth.Unregister("kafka." + id)
 		producer.Close()
 	}
 }
@@ -14,14 +14,15 @@ const (
 type Config struct {
 	// common
 	Id               string // optional
-	Role             string // optional, default is consumer
+	Role             string `valid:"oneof=consumer producer"` // optional, default is consumer
 	Auto             bool   // optional
-	BootstrapServers string // must
-	SecurityProtocol string // optional
-	SASLUsername     string // optional
-	SASLPassword     string // optional
+	BootstrapServers string `valid:"required"`                                             // must
+	SecurityProtocol string `valid:"oneofci=plaintext sasl_plaintext sasl_ssl ssl"`        // optional
+	SASLUsername     string `valid:"required_if=SecurityProtocol sasl_plaintext sasl_ssl"` // optional
+	SASLPassword     string `valid:"required_if=SecurityProtocol sasl_plaintext sasl_ssl"` // optional
 	SASLMechanism    string // optional
 	SSLCaLocation    string // optional
+	HealthLevel      string `valid:"oneof=critical noncritical none"` // optional, 参考 zhealth.LevelCritical
 
 	// producer
 	Topic                 string                     // must
@@ -1,10 +1,13 @@
 package zkafka
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/yyliziqiu/zlib/ztrace"
 )
 
 func NewProducer(config Config) (*kafka.Producer, error) {
@@ -34,16 +37,35 @@ func NewProducer(config Config) (*kafka.Producer, error) {
 }
 
 func Produce(producer *kafka.Producer, topic string, message []byte) error {
-	return producer.Produce(&kafka.Message{
+	return ProduceWithContext(context.Background(), producer, topic, message)
+}
+
+func ProduceObject(producer *kafka.Producer, topic string, object interface{}) error {
+	return ProduceObjectWithContext(context.Background(), producer, topic, object)
+}
+
+// ProduceWithContext 发送消息，并将 ctx 中的 SpanContext 写入消息头
+func ProduceWithContext(ctx context.Context, producer *kafka.Producer, topic string, message []byte) error {
+	msg := &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
 		Value:          message,
-	}, nil)
+	}
+
+	ctx, span := startSpan(ctx, msg, "send", ztrace.SpanKindProducer)
+	defer span.End()
+
+	ztrace.Inject(ctx, HeaderCarrier{Message: msg})
+
+	err := producer.Produce(msg, nil)
+	span.SetError(err)
+
+	return err
 }
 
-func ProduceObject(producer *kafka.Producer, topic string, object interface{}) error {
+func ProduceObjectWithContext(ctx context.Context, producer *kafka.Producer, topic string, object interface{}) error {
 	message, err := json.Marshal(object)
 	if err != nil {
 		return err
 	}
-	return Produce(producer, topic, message)
+	return ProduceWithContext(ctx, producer, topic, message)
 }
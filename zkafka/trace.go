@@ -0,0 +1,52 @@
+package zkafka
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/yyliziqiu/zlib/ztrace"
+)
+
+// HeaderCarrier 以 kafka 消息头作为 traceparent 的载体
+type HeaderCarrier struct {
+	Message *kafka.Message
+}
+
+func (c HeaderCarrier) Get(key string) string {
+	for _, header := range c.Message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c HeaderCarrier) Set(key string, value string) {
+	for i, header := range c.Message.Headers {
+		if header.Key == key {
+			c.Message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.Message.Headers = append(c.Message.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// MessageContext 返回包含消息头中上游 SpanContext 的 ctx
+func MessageContext(ctx context.Context, msg *kafka.Message) context.Context {
+	return ztrace.Extract(ctx, HeaderCarrier{Message: msg})
+}
+
+func startSpan(ctx context.Context, msg *kafka.Message, operation string, kind int) (context.Context, *ztrace.Span) {
+	topic := ""
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+
+	ctx, span := ztrace.Start(ctx, topic+" "+operation, kind)
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.destination", topic)
+	span.SetAttribute("messaging.operation", operation)
+
+	return ctx, span
+}
@@ -0,0 +1,302 @@
+package zlog
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+// 异步写日志时缓冲区已满的处理策略
+const (
+	AsyncPolicyBlock        = "block"          // 阻塞等待
+	AsyncPolicyDropNewest   = "drop_newest"    // 丢弃新日志
+	AsyncPolicyDropLowLevel = "drop_low_level" // 优先丢弃缓冲区中最早的 trace、debug、info 日志，没有时丢弃新日志
+)
+
+var _droppedTotal = zmetrics.NewCounter(
+	"zlog_dropped_entries_total",
+	"Number of log entries dropped because the async buffer was full.",
+	"logger", "level",
+)
+
+type asyncEntry struct {
+	dst   io.Writer
+	level logrus.Level
+	data  []byte
+}
+
+// AsyncWriter 将日志写入有界环形缓冲区，由后台 goroutine 写入目标 writer
+type AsyncWriter struct {
+	name   string
+	policy string
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	buf      []asyncEntry
+	head     int
+	count    int
+	writing  bool
+	closed   bool
+	done     chan struct{}
+
+	droppedMu sync.Mutex
+	dropped   map[logrus.Level]int64
+}
+
+// NewAsyncWriter 创建 AsyncWriter，name 用于区分指标
+func NewAsyncWriter(name string, size int, policy string) *AsyncWriter {
+	if size <= 0 {
+		size = 8192
+	}
+	if policy == "" {
+		policy = AsyncPolicyBlock
+	}
+
+	w := &AsyncWriter{
+		name:    name,
+		policy:  policy,
+		buf:     make([]asyncEntry, size),
+		done:    make(chan struct{}),
+		dropped: make(map[logrus.Level]int64, 8),
+	}
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+	w.idle = sync.NewCond(&w.mu)
+
+	go w.loop()
+
+	registerAsyncWriter(w)
+
+	return w
+}
+
+// Wrap 返回写入 dst 的 writer，level 用于缓冲区已满时按等级丢弃
+func (w *AsyncWriter) Wrap(dst io.Writer, level logrus.Level) io.Writer {
+	return &asyncLevelWriter{w: w, dst: dst, level: level}
+}
+
+type asyncLevelWriter struct {
+	w     *AsyncWriter
+	dst   io.Writer
+	level logrus.Level
+}
+
+func (lw *asyncLevelWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	lw.w.push(asyncEntry{dst: lw.dst, level: lw.level, data: data})
+	return len(p), nil
+}
+
+func isLowLevel(level logrus.Level) bool {
+	return level >= logrus.InfoLevel
+}
+
+func (w *AsyncWriter) push(entry asyncEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		// 关闭后同步写入，避免丢失退出过程中的日志
+		_, _ = entry.dst.Write(entry.data)
+		return
+	}
+
+	for w.count == len(w.buf) {
+		switch w.policy {
+		case AsyncPolicyDropNewest:
+			w.drop(entry.level)
+			return
+		case AsyncPolicyDropLowLevel:
+			if isLowLevel(entry.level) || !w.evictLowLevel() {
+				w.drop(entry.level)
+				return
+			}
+		default:
+			w.notFull.Wait()
+			if w.closed {
+				_, _ = entry.dst.Write(entry.data)
+				return
+			}
+		}
+	}
+
+	w.buf[(w.head+w.count)%len(w.buf)] = entry
+	w.count++
+	w.notEmpty.Signal()
+}
+
+// evictLowLevel 删除缓冲区中最早的低等级日志，调用前必须加锁
+func (w *AsyncWriter) evictLowLevel() bool {
+	size := len(w.buf)
+	for i := 0; i < w.count; i++ {
+		idx := (w.head + i) % size
+		if !isLowLevel(w.buf[idx].level) {
+			continue
+		}
+		w.drop(w.buf[idx].level)
+		for j := i; j < w.count-1; j++ {
+			w.buf[(w.head+j)%size] = w.buf[(w.head+j+1)%size]
+		}
+		w.count--
+		w.buf[(w.head+w.count)%size] = asyncEntry{}
+		return true
+	}
+	return false
+}
+
+func (w *AsyncWriter) drop(level logrus.Level) {
+	w.droppedMu.Lock()
+	w.dropped[level]++
+	w.droppedMu.Unlock()
+	_droppedTotal.Inc(w.name, level.String())
+}
+
+func (w *AsyncWriter) loop() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for w.count == 0 && !w.closed {
+			w.writing = false
+			w.idle.Broadcast()
+			w.notEmpty.Wait()
+		}
+		if w.count == 0 && w.closed {
+			w.writing = false
+			w.idle.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+		entry := w.buf[w.head]
+		w.buf[w.head] = asyncEntry{}
+		w.head = (w.head + 1) % len(w.buf)
+		w.count--
+		w.writing = true
+		w.notFull.Signal()
+		w.mu.Unlock()
+
+		_, _ = entry.dst.Write(entry.data)
+	}
+}
+
+// Flush 等待缓冲区中的日志全部写入，最多等待 timeout
+func (w *AsyncWriter) Flush(timeout time.Duration) bool {
+	expired := false
+	timer := time.AfterFunc(timeout, func() {
+		w.mu.Lock()
+		expired = true
+		w.idle.Broadcast()
+		w.mu.Unlock()
+	})
+	defer timer.Stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count > 0 || w.writing {
+		if expired {
+			return false
+		}
+		w.idle.Wait()
+	}
+
+	return true
+}
+
+// Close 写入缓冲区中的所有日志并停止后台 goroutine，之后的日志同步写入
+func (w *AsyncWriter) Close() {
+	unregisterAsyncWriter(w)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// Dropped 返回各等级被丢弃的日志数量
+func (w *AsyncWriter) Dropped() map[string]int64 {
+	w.droppedMu.Lock()
+	defer w.droppedMu.Unlock()
+
+	result := make(map[string]int64, len(w.dropped))
+	for level, n := range w.dropped {
+		result[level.String()] = n
+	}
+
+	return result
+}
+
+var (
+	_asyncWritersMu sync.Mutex
+	_asyncWriters   = make(map[*AsyncWriter]struct{})
+	_exitHandler    sync.Once
+)
+
+func registerAsyncWriter(w *AsyncWriter) {
+	_asyncWritersMu.Lock()
+	_asyncWriters[w] = struct{}{}
+	_asyncWritersMu.Unlock()
+
+	// logrus 的 Fatal 日志在退出进程前会调用
+	_exitHandler.Do(func() {
+		logrus.RegisterExitHandler(func() { Finally(DefaultFlushTimeout) })
+	})
+}
+
+func unregisterAsyncWriter(w *AsyncWriter) {
+	_asyncWritersMu.Lock()
+	delete(_asyncWriters, w)
+	_asyncWritersMu.Unlock()
+}
+
+// DefaultFlushTimeout Flush 的默认等待时间
+const DefaultFlushTimeout = 5 * time.Second
+
+// Flush 输出所有 Sampler 当前周期的汇总日志，等待所有 AsyncWriter 缓冲区中的日志写入，并发送所有 Sink 缓冲区中的日志，
+// 最多等待 timeout，全部完成时返回 true。Flush 不关闭任何组件，之后可以继续写日志
+func Flush(timeout time.Duration) bool {
+	flushSamplers()
+
+	_asyncWritersMu.Lock()
+	writers := make([]*AsyncWriter, 0, len(_asyncWriters))
+	for w := range _asyncWriters {
+		writers = append(writers, w)
+	}
+	_asyncWritersMu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	ok := true
+	for _, w := range writers {
+		if !w.Flush(time.Until(deadline)) {
+			ok = false
+		}
+	}
+	if !flushSinks(deadline) {
+		ok = false
+	}
+
+	return ok
+}
+
+// Finally 关闭所有 Sampler 并输出其汇总日志，之后同 Flush，用于退出前调用，之后的日志不再采样
+func Finally(timeout time.Duration) bool {
+	closeSamplers(nil)
+	return Flush(timeout)
+}
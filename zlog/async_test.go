@@ -0,0 +1,55 @@
+package zlog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slowWriter 阻塞直到 release 被关闭
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriterDropLowLevel(t *testing.T) {
+	dst := &slowWriter{release: make(chan struct{})}
+
+	w := NewAsyncWriter("test", 2, AsyncPolicyDropLowLevel)
+	defer w.Close()
+
+	info := w.Wrap(dst, logrus.InfoLevel)
+	warn := w.Wrap(dst, logrus.WarnLevel)
+
+	// 第一条被后台 goroutine 取出后阻塞，其余两条占满缓冲区
+	_, _ = info.Write([]byte("i1\n"))
+	time.Sleep(50 * time.Millisecond)
+	_, _ = info.Write([]byte("i2\n"))
+	_, _ = warn.Write([]byte("w1\n"))
+
+	_, _ = warn.Write([]byte("w2\n")) // 挤掉 i2
+	_, _ = info.Write([]byte("i3\n")) // 被丢弃
+
+	close(dst.release)
+	if !w.Flush(time.Second) {
+		t.Fatal("flush timeout")
+	}
+
+	if got := dst.buf.String(); got != "i1\nw1\nw2\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+	if dropped := w.Dropped(); dropped["info"] != 2 {
+		t.Errorf("unexpected dropped: %v", dropped)
+	}
+}
@@ -0,0 +1,83 @@
+package zlog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/ztrace"
+)
+
+const (
+	// RequestIdHeader 传递请求 id 的请求头和响应头
+	RequestIdHeader = "X-Request-Id"
+
+	// 日志中的字段名
+	RequestIdField = "request_id"
+	TraceIdField   = "trace_id"
+	SpanIdField    = "span_id"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId 将请求 id 保存到 ctx
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId 返回 ctx 中的请求 id，不存在时返回空字符串
+func RequestId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+var (
+	_contextKeysMu sync.RWMutex
+	_contextKeys   = make(map[string]any)
+)
+
+// AddContextKey 通过 WithContext 记录日志时，将 ctx.Value(key) 以 field 为字段名写入日志
+func AddContextKey(field string, key any) {
+	_contextKeysMu.Lock()
+	_contextKeys[field] = key
+	_contextKeysMu.Unlock()
+}
+
+// ContextHook 将 ctx 中的请求 id、trace id 和通过 AddContextKey 添加的值写入日志，
+// 只对通过 WithContext 记录的日志生效，默认已添加到所有日志
+type ContextHook struct{}
+
+func (ContextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (ContextHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		return nil
+	}
+
+	if id := RequestId(ctx); id != "" {
+		entry.Data[RequestIdField] = id
+	}
+
+	sc := ztrace.SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		entry.Data[TraceIdField] = sc.TraceID.String()
+		entry.Data[SpanIdField] = sc.SpanID.String()
+	}
+
+	_contextKeysMu.RLock()
+	for field, key := range _contextKeys {
+		if value := ctx.Value(key); value != nil {
+			entry.Data[field] = value
+		}
+	}
+	_contextKeysMu.RUnlock()
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package zlog
+
+import (
+	"github.com/rifflock/lfshook"
+	"github.com/sirupsen/logrus"
+)
+
+// addHook 将 hook 插入到写文件和投递日志的 hook 之前，使其对日志内容的修改能够写入文件和投递
+func addHook(logger *logrus.Logger, hook logrus.Hook) {
+	logger.ReplaceHooks(insertHook(logger.Hooks, hook))
+}
+
+func insertHook(hooks logrus.LevelHooks, hook logrus.Hook) logrus.LevelHooks {
+	result := make(logrus.LevelHooks, len(hooks))
+	for level, list := range hooks {
+		result[level] = list
+	}
+
+	for _, level := range hook.Levels() {
+		list := result[level]
+
+		i := len(list)
+		for j, h := range list {
+			if isWriterHook(h) {
+				i = j
+				break
+			}
+		}
+
+		inserted := make([]logrus.Hook, 0, len(list)+1)
+		inserted = append(inserted, list[:i]...)
+		inserted = append(inserted, hook)
+		inserted = append(inserted, list[i:]...)
+		result[level] = inserted
+	}
+
+	return result
+}
+
+func isWriterHook(hook logrus.Hook) bool {
+	switch hook.(type) {
+	case *lfshook.LfsHook, *Sink:
+		return true
+	default:
+		return false
+	}
+}
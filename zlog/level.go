@@ -0,0 +1,195 @@
+package zlog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultLoggerName = "default"
+	ConsoleLoggerName = "console"
+)
+
+var (
+	_levelMu sync.Mutex
+	_named   = make(map[string]*logrus.Logger, 8)
+
+	_revertTimer  *time.Timer
+	_revertAt     time.Time
+	_revertLevels map[string]logrus.Level
+	_revertGen    uint64 // 每次设置或取消恢复时加 1，已触发的旧定时器据此放弃恢复
+)
+
+// registerNamed 注册 name 对应的日志，并关闭被替换的日志
+func registerNamed(name string, logger *logrus.Logger) {
+	_levelMu.Lock()
+	old := _named[name]
+	_named[name] = logger
+	_levelMu.Unlock()
+
+	if old != nil && old != logger {
+		closeLogger(old)
+	}
+}
+
+// loggers 返回所有可修改等级的日志，调用前必须加锁
+func loggers() map[string]*logrus.Logger {
+	result := make(map[string]*logrus.Logger, len(_named)+2)
+	for name, logger := range _named {
+		result[name] = logger
+	}
+	if Default != nil {
+		result[DefaultLoggerName] = Default
+	}
+	if Console != nil {
+		result[ConsoleLoggerName] = Console
+	}
+	return result
+}
+
+// Levels 返回 Default、Console 及 NewWithName 创建的日志的等级
+func Levels() map[string]string {
+	_levelMu.Lock()
+	defer _levelMu.Unlock()
+
+	result := make(map[string]string, len(_named)+2)
+	for name, logger := range loggers() {
+		result[name] = logger.GetLevel().String()
+	}
+
+	return result
+}
+
+// LoggerNames 返回所有可修改等级的日志名称
+func LoggerNames() []string {
+	levels := Levels()
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RevertAt 返回临时修改的日志等级恢复的时间，没有临时修改时返回零值
+func RevertAt() time.Time {
+	_levelMu.Lock()
+	defer _levelMu.Unlock()
+
+	return _revertAt
+}
+
+// SetLevels 修改日志等级，logger 为空时修改所有日志，duration 大于 0 时到期后恢复为修改前的等级
+func SetLevels(logger string, level string, duration time.Duration) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level [%s]", level)
+	}
+
+	_levelMu.Lock()
+	defer _levelMu.Unlock()
+
+	targets := loggers()
+	if logger != "" {
+		l, ok := targets[logger]
+		if !ok {
+			return fmt.Errorf("logger [%s] not found", logger)
+		}
+		targets = map[string]*logrus.Logger{logger: l}
+	}
+
+	if duration > 0 {
+		// 连续临时修改时恢复为第一次修改前的等级
+		if _revertLevels == nil {
+			_revertLevels = make(map[string]logrus.Level, len(targets))
+		}
+		for name, l := range targets {
+			if _, ok := _revertLevels[name]; !ok {
+				_revertLevels[name] = l.GetLevel()
+			}
+		}
+		if _revertTimer != nil {
+			_revertTimer.Stop()
+		}
+		_revertGen++
+		gen := _revertGen
+		_revertAt = time.Now().Add(duration)
+		_revertTimer = time.AfterFunc(duration, func() { revertLevels(gen) })
+	} else {
+		for name := range targets {
+			delete(_revertLevels, name)
+		}
+		if len(_revertLevels) == 0 {
+			cancelRevert()
+		}
+	}
+
+	for _, l := range targets {
+		l.SetLevel(lvl)
+	}
+	if logger == "" {
+		_config.Level = lvl.String()
+	}
+
+	return nil
+}
+
+func revertLevels(gen uint64) {
+	_levelMu.Lock()
+	defer _levelMu.Unlock()
+
+	if gen != _revertGen {
+		return
+	}
+
+	targets := loggers()
+	for name, lvl := range _revertLevels {
+		if l, ok := targets[name]; ok {
+			l.SetLevel(lvl)
+		}
+	}
+	if lvl, ok := _revertLevels[DefaultLoggerName]; ok {
+		_config.Level = lvl.String()
+	}
+
+	_revertTimer = nil
+	_revertAt = time.Time{}
+	_revertLevels = nil
+}
+
+// cancelRevert 取消临时修改的恢复，调用前必须加锁
+func cancelRevert() {
+	if _revertTimer != nil {
+		_revertTimer.Stop()
+	}
+	_revertGen++
+	_revertTimer = nil
+	_revertAt = time.Time{}
+	_revertLevels = nil
+}
+
+// StepLevel 将所有日志的等级调整 delta 级，delta 为正数时输出更多日志，到达 trace 或 panic 后不再变化
+func StepLevel(delta int) {
+	_levelMu.Lock()
+	defer _levelMu.Unlock()
+
+	for _, l := range loggers() {
+		lvl := int(l.GetLevel()) + delta
+		if lvl < int(logrus.PanicLevel) {
+			lvl = int(logrus.PanicLevel)
+		}
+		if lvl > int(logrus.TraceLevel) {
+			lvl = int(logrus.TraceLevel)
+		}
+		l.SetLevel(logrus.Level(lvl))
+	}
+	if Default != nil {
+		_config.Level = Default.GetLevel().String()
+	}
+
+	cancelRevert()
+}
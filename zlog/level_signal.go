@@ -0,0 +1,32 @@
+//go:build !windows
+
+package zlog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var _levelSignalOnce sync.Once
+
+// LevelOnSignal 收到 SIGUSR1 时将所有日志的等级提高一级（输出更多日志），收到 SIGUSR2 时降低一级，多次调用只生效一次
+func LevelOnSignal() {
+	_levelSignalOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+		go func() {
+			for sig := range ch {
+				if sig == syscall.SIGUSR1 {
+					StepLevel(1)
+				} else {
+					StepLevel(-1)
+				}
+				if Default != nil {
+					Default.Warnf("Log level changed by signal, signal: %s, level: %s.", sig, Default.GetLevel())
+				}
+			}
+		}()
+	})
+}
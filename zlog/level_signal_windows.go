@@ -0,0 +1,4 @@
+package zlog
+
+// LevelOnSignal Windows 不支持 SIGUSR1 和 SIGUSR2
+func LevelOnSignal() {}
@@ -0,0 +1,63 @@
+package zlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLevels(t *testing.T) {
+	err := Init(Config{Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := NewWithName("access")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = SetLevels("", "debug", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SetLevels("access", "trace", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Default.GetLevel().String() != "debug" || access.GetLevel().String() != "trace" {
+		t.Errorf("unexpected levels: %v", Levels())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if Default.GetLevel().String() != "info" || access.GetLevel().String() != "info" || !RevertAt().IsZero() {
+		t.Errorf("levels should be reverted: %v", Levels())
+	}
+
+	if err = SetLevels("missing", "info", 0); err == nil {
+		t.Error("expected error for unknown logger")
+	}
+	if err = SetLevels("", "verbose", 0); err == nil {
+		t.Error("expected error for invalid level")
+	}
+
+	// 已触发的旧定时器不恢复新的临时修改
+	err = SetLevels("", "debug", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_levelMu.Lock()
+	stale := _revertGen - 1
+	_levelMu.Unlock()
+	revertLevels(stale)
+	if Default.GetLevel().String() != "debug" || RevertAt().IsZero() {
+		t.Errorf("stale revert should be ignored: %v", Levels())
+	}
+	err = SetLevels("", "info", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	StepLevel(1)
+	if Console.GetLevel().String() != "debug" {
+		t.Errorf("unexpected level after step: %v", Levels())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	rotate "github.com/lestrrat/go-file-rotatelogs"
@@ -15,7 +16,9 @@ import (
 )
 
 var (
-	_config Config
+	_config  Config
+	_hooksMu sync.Mutex
+	_hooks   = []logrus.Hook{ContextHook{}}
 
 	Default *logrus.Logger
 
@@ -25,24 +28,122 @@ var (
 func Init(config Config) (err error) {
 	_config = config.Default()
 
+	old := Default
 	Default, err = New(_config)
 	if err != nil {
 		return err
 	}
+	if old != nil {
+		closeLogger(old)
+	}
 
 	Console, err = NewConsoleLogger(_config)
 	if err != nil {
 		return err
 	}
+	for _, hook := range hooks() {
+		addHook(Console, hook)
+	}
 
 	return nil
 }
 
 func New(config Config) (*logrus.Logger, error) {
+	var (
+		logger *logrus.Logger
+		err    error
+	)
+	var closers []func()
 	if config.Console {
-		return NewConsoleLogger(config)
+		logger, err = NewConsoleLogger(config)
+	} else {
+		logger, err = newFileLogger(config, &closers)
+	}
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+
+	for _, hook := range hooks() {
+		addHook(logger, hook)
+	}
+
+	if config.Sampling.Enabled() {
+		addHook(logger, NewSampler(config.Name, logger, config.Sampling))
+	}
+
+	err = addSinks(logger, config, &closers)
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+
+	ownClosers(logger, closers)
+
+	return logger, nil
+}
+
+var (
+	_closersMu sync.Mutex
+	_closers   = make(map[*logrus.Logger][]func())
+)
+
+func addCloser(closers *[]func(), f func()) {
+	if closers != nil {
+		*closers = append(*closers, f)
+	}
+}
+
+// closeAll 按创建的相反顺序关闭，先关闭 Sink 和 AsyncWriter，再关闭其写入的文件
+func closeAll(closers []func()) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		closers[i]()
 	}
-	return NewFileLogger(config)
+}
+
+// ownClosers 记录 logger 创建的 RotateWriter、AsyncWriter 和 Sink，logger 被替换时关闭
+func ownClosers(logger *logrus.Logger, closers []func()) {
+	if len(closers) == 0 {
+		return
+	}
+	_closersMu.Lock()
+	_closers[logger] = closers
+	_closersMu.Unlock()
+}
+
+// closeLogger 关闭被替换的 logger 的 Sampler、Sink、AsyncWriter 和日志文件，
+// 仍持有该 logger 的调用方之后的日志同步写入，RotateWriter 会重新打开文件
+func closeLogger(logger *logrus.Logger) {
+	closeSamplers(logger)
+
+	_closersMu.Lock()
+	closers := _closers[logger]
+	delete(_closers, logger)
+	_closersMu.Unlock()
+
+	closeAll(closers)
+}
+
+// AddHook 为 Default、Console 及之后通过 New 创建的日志添加 hook
+func AddHook(hook logrus.Hook) {
+	_hooksMu.Lock()
+	_hooks = append(_hooks, hook)
+	_hooksMu.Unlock()
+
+	if Default != nil {
+		addHook(Default, hook)
+	}
+	if Console != nil {
+		addHook(Console, hook)
+	}
+}
+
+// hooks 返回 AddHook 添加的 hook 的副本
+func hooks() []logrus.Hook {
+	_hooksMu.Lock()
+	defer _hooksMu.Unlock()
+
+	return append([]logrus.Hook(nil), _hooks...)
 }
 
 func NewConsoleLogger(config Config) (*logrus.Logger, error) {
@@ -60,6 +161,11 @@ func NewConsoleLogger(config Config) (*logrus.Logger, error) {
 	return logger, nil
 }
 
+// SetLevel 修改 Default、Console 及 NewWithName 创建的日志的等级，参考 SetLevels
+func SetLevel(name string) {
+	_ = SetLevels("", level(name).String(), 0)
+}
+
 func level(name string) logrus.Level {
 	lvl, err := logrus.ParseLevel(name)
 	if err != nil {
@@ -78,20 +184,32 @@ func formatter(config Config) logrus.Formatter {
 		timestampFormat = "2006-01-02 15:04:05"
 	}
 
+	var f logrus.Formatter
 	switch formatterName {
 	case JSONFormatterName:
-		return &logrus.JSONFormatter{
+		f = &logrus.JSONFormatter{
 			TimestampFormat: timestampFormat,
 		}
 	default:
-		return &logrus.TextFormatter{
+		f = &logrus.TextFormatter{
 			DisableQuote:    true,
 			TimestampFormat: timestampFormat,
 		}
 	}
+
+	if config.Sampling.Enabled() {
+		f = samplingFormatter{f}
+	}
+
+	return f
 }
 
 func NewFileLogger(config Config) (*logrus.Logger, error) {
+	return newFileLogger(config, nil)
+}
+
+// newFileLogger closers 不为 nil 时记录创建的 RotateWriter 和 AsyncWriter 的关闭函数
+func newFileLogger(config Config, closers *[]func()) (*logrus.Logger, error) {
 	logger := logrus.New()
 
 	// 禁止控制台输出
@@ -104,7 +222,7 @@ func NewFileLogger(config Config) (*logrus.Logger, error) {
 	logger.SetLevel(level(config.Level))
 
 	// 日志按天分割
-	hook, err := getRotationHook(config)
+	hook, err := getRotationHook(config, closers)
 	if err != nil {
 		return nil, fmt.Errorf("create hook failed [%v]", err)
 	}
@@ -113,24 +231,19 @@ func NewFileLogger(config Config) (*logrus.Logger, error) {
 	return logger, nil
 }
 
-func getRotationHook(config Config) (*lfshook.LfsHook, error) {
+func getRotationHook(config Config, closers *[]func()) (*lfshook.LfsHook, error) {
 	switch config.RotationLevel {
 	case 0:
-		return newRotationHook0(config)
+		return newRotationHook0(config, closers)
 	case 1:
-		return newRotationHook1(config)
+		return newRotationHook1(config, closers)
 	default:
-		return newRotationHook2(config)
+		return newRotationHook2(config, closers)
 	}
 }
 
-func newRotationHook0(config Config) (*lfshook.LfsHook, error) {
-	var (
-		name         = config.Name
-		path         = config.Path
-		maxAge       = config.MaxAge
-		rotationTime = config.RotationTime
-	)
+func newRotationHook0(config Config, closers *[]func()) (*lfshook.LfsHook, error) {
+	name := config.Name
 
 	// 确保日志目录存在
 	err := zfile.MakeDirIfNotExist(config.Path)
@@ -144,35 +257,30 @@ func newRotationHook0(config Config) (*lfshook.LfsHook, error) {
 	}
 
 	// 创建分割器
-	rotation, err := NewRotation(path, name+"%Y%m%d.log", maxAge, rotationTime)
+	rotation, err := newWriter(config, name, closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
-	errorRotation, err := NewRotation(path, name+"error-%Y%m%d.log", maxAge, rotationTime)
+	errorRotation, err := newWriter(config, name+"error-", closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
 
-	return lfshook.NewHook(lfshook.WriterMap{
+	return newHook(config, lfshook.WriterMap{
 		logrus.DebugLevel: rotation,
 		logrus.InfoLevel:  rotation,
 		logrus.WarnLevel:  rotation,
 		logrus.ErrorLevel: errorRotation,
 		logrus.FatalLevel: errorRotation,
 		logrus.PanicLevel: errorRotation,
-	}, formatter(config)), nil
+	}, closers), nil
 }
 
-func newRotationHook1(config Config) (*lfshook.LfsHook, error) {
-	var (
-		name         = config.Name
-		path         = config.Path
-		maxAge       = config.MaxAge
-		rotationTime = config.RotationTime
-	)
+func newRotationHook1(config Config, closers *[]func()) (*lfshook.LfsHook, error) {
+	name := config.Name
 
 	// 确保日志目录存在
-	err := zfile.MakeDirIfNotExist(path)
+	err := zfile.MakeDirIfNotExist(config.Path)
 	if err != nil {
 		return nil, fmt.Errorf("create logs dir failed [%v]", err)
 	}
@@ -183,21 +291,21 @@ func newRotationHook1(config Config) (*lfshook.LfsHook, error) {
 	}
 
 	// 创建分割器
-	rotation, err := NewRotation(path, name+"%Y%m%d.log", maxAge, rotationTime)
+	rotation, err := newWriter(config, name, closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
 
-	return lfshook.NewHook(rotation, formatter(config)), nil
+	writers := make(lfshook.WriterMap, len(logrus.AllLevels))
+	for _, lvl := range logrus.AllLevels {
+		writers[lvl] = rotation
+	}
+
+	return newHook(config, writers, closers), nil
 }
 
-func newRotationHook2(config Config) (*lfshook.LfsHook, error) {
-	var (
-		name         = config.Name
-		path         = config.Path
-		maxAge       = config.MaxAge
-		rotationTime = config.RotationTime
-	)
+func newRotationHook2(config Config, closers *[]func()) (*lfshook.LfsHook, error) {
+	name := config.Name
 
 	// 确保日志目录存在
 	err := zfile.MakeDirIfNotExist(config.Path)
@@ -211,31 +319,75 @@ func newRotationHook2(config Config) (*lfshook.LfsHook, error) {
 	}
 
 	// 创建分割器
-	debugRotation, err := NewRotation(path, name+"debug-%Y%m%d.log", maxAge, rotationTime)
+	debugRotation, err := newWriter(config, name+"debug-", closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
-	infoRotation, err := NewRotation(path, name+"info-%Y%m%d.log", maxAge, rotationTime)
+	infoRotation, err := newWriter(config, name+"info-", closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
-	warnRotation, err := NewRotation(path, name+"warn-%Y%m%d.log", maxAge, rotationTime)
+	warnRotation, err := newWriter(config, name+"warn-", closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
-	errorRotation, err := NewRotation(path, name+"error-%Y%m%d.log", maxAge, rotationTime)
+	errorRotation, err := newWriter(config, name+"error-", closers)
 	if err != nil {
 		return nil, fmt.Errorf("create rotate failed [%v]", err)
 	}
 
-	return lfshook.NewHook(lfshook.WriterMap{
+	return newHook(config, lfshook.WriterMap{
 		logrus.DebugLevel: debugRotation,
 		logrus.InfoLevel:  infoRotation,
 		logrus.WarnLevel:  warnRotation,
 		logrus.ErrorLevel: errorRotation,
 		logrus.FatalLevel: errorRotation,
 		logrus.PanicLevel: errorRotation,
-	}, formatter(config)), nil
+	}, closers), nil
+}
+
+// newHook 创建写文件的 hook，开启 Async 时通过 AsyncWriter 异步写入
+func newHook(config Config, writers lfshook.WriterMap, closers *[]func()) *lfshook.LfsHook {
+	if config.Async {
+		async := NewAsyncWriter(config.Name, config.AsyncBufferSize, config.AsyncPolicy)
+		addCloser(closers, async.Close)
+		for lvl, w := range writers {
+			writers[lvl] = async.Wrap(w, lvl)
+		}
+	}
+	return lfshook.NewHook(writers, formatter(config))
+}
+
+// newWriter 创建日志文件的分割器，MaxSize 大于 0 时使用 RotateWriter，否则使用按时间分割的 rotatelogs，
+// 如 name 为 app-error- 时分别写入 app-error.log 和 app-error-20240402.log
+func newWriter(config Config, name string, closers *[]func()) (io.Writer, error) {
+	if config.MaxSize <= 0 {
+		w, err := NewRotation(config.Path, name+"%Y%m%d.log", config.MaxAge, config.RotationTime)
+		if err != nil {
+			return nil, err
+		}
+		addCloser(closers, func() { _ = w.Close() })
+		return w, nil
+	}
+
+	if config.ReopenOnSignal {
+		ReopenOnSignal()
+	}
+
+	w, err := NewRotateWriter(filepath.Join(config.Path, strings.TrimSuffix(name, "-")+".log"), RotateOptions{
+		MaxSize:      int64(config.MaxSize) * megabyte,
+		RotationTime: config.RotationTime,
+		MaxBackups:   config.MaxBackups,
+		MaxAge:       config.MaxAge,
+		MaxTotalSize: int64(config.MaxTotalSize) * megabyte,
+		Compress:     config.Compress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	addCloser(closers, func() { _ = w.Close() })
+
+	return w, nil
 }
 
 func NewRotation(dirname string, filename string, maxAge time.Duration, RotationTime time.Duration) (*rotate.RotateLogs, error) {
@@ -245,7 +397,14 @@ func NewRotation(dirname string, filename string, maxAge time.Duration, Rotation
 func NewWithName(name string) (*logrus.Logger, error) {
 	config := _config
 	config.Name = name
-	return New(config)
+
+	logger, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	registerNamed(name, logger)
+
+	return logger, nil
 }
 
 func NewWithNameMust(name string) *logrus.Logger {
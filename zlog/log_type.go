@@ -11,11 +11,21 @@ type Config struct {
 	Console         bool
 	Path            string
 	Name            string
-	Level           string
+	Level           string `valid:"oneofci=trace debug info warn warning error fatal panic"`
 	MaxAge          time.Duration
 	RotationTime    time.Duration
 	RotationLevel   int
-	Formatter       string
+	MaxSize         int            // optional, 单个日志文件的最大大小，单位 MB，大于 0 时使用 RotateWriter 按大小和时间分割
+	MaxBackups      int            // optional, MaxSize 大于 0 时生效，保留的历史文件数量
+	MaxTotalSize    int            // optional, MaxSize 大于 0 时生效，单个日志所有文件的总大小上限，单位 MB
+	Compress        bool           // optional, MaxSize 大于 0 时生效，使用 gzip 压缩历史文件
+	ReopenOnSignal  bool           // optional, MaxSize 大于 0 时生效，收到 SIGHUP 信号时重新打开日志文件
+	Async           bool           // optional, 异步写日志文件
+	AsyncBufferSize int            // optional, Async 为 true 时生效，缓冲区可容纳的日志条数
+	AsyncPolicy     string         `valid:"oneof=block drop_newest drop_low_level"` // optional, Async 为 true 时生效，缓冲区已满时的处理策略，默认为 block
+	Sampling        SamplingConfig // optional, 对重复日志采样
+	Sinks           []SinkConfig   // optional, 将日志投递到 syslog、HTTP、Elasticsearch 等外部系统
+	Formatter       string         `valid:"oneof=text json"`
 	EnableCaller    bool
 	TimestampFormat string
 }
@@ -0,0 +1,411 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yyliziqiu/zlib/zcompress"
+)
+
+const (
+	// 历史文件名中的时间格式，如 app-20240402T192731.000.log，同一毫秒内多次分割时追加序号，如 app-20240402T192731.000-1.log
+	backupTimeFormat = "20060102T150405.000"
+
+	compressSuffix = ".gz"
+
+	megabyte = 1024 * 1024
+)
+
+// RotateOptions RotateWriter 的分割和保留策略，为 0 的字段不生效
+type RotateOptions struct {
+	MaxSize      int64         // 单个文件的最大字节数，超出时分割
+	RotationTime time.Duration // 按时间分割的周期
+	MaxBackups   int           // 保留的历史文件数量
+	MaxAge       time.Duration // 历史文件的最长保留时间
+	MaxTotalSize int64         // 当前文件和历史文件的总字节数上限，超出时从最旧的历史文件开始删除
+	Compress     bool          // 是否使用 gzip 压缩历史文件
+}
+
+// RotateWriter 按大小和时间分割的日志文件，分割后的历史文件命名为 <name>-<time>.log[.gz]
+type RotateWriter struct {
+	path    string
+	options RotateOptions
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+
+	millCh chan struct{}
+	closed chan struct{}
+}
+
+// NewRotateWriter 创建 RotateWriter，path 为当前日志文件的路径，如 logs/app.log
+func NewRotateWriter(path string, options RotateOptions) (*RotateWriter, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("create logs dir failed [%v]", err)
+	}
+
+	w := &RotateWriter{
+		path:    path,
+		options: options,
+		millCh:  make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+
+	err = w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	go w.millLoop()
+	w.triggerMill()
+
+	registerRotateWriter(w)
+
+	return w, nil
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		err := w.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		err := w.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.options.MaxSize > 0 && w.size > 0 && w.size+n > w.options.MaxSize {
+		return true
+	}
+	if w.options.RotationTime > 0 && !w.currentPeriod().Equal(w.period) {
+		return true
+	}
+	return false
+}
+
+func (w *RotateWriter) currentPeriod() time.Time {
+	if w.options.RotationTime <= 0 {
+		return time.Time{}
+	}
+	return truncatePeriod(time.Now(), w.options.RotationTime)
+}
+
+// truncatePeriod 按本地时间对齐周期，d 不超过 24h 时以本地零点为起点，与 rotatelogs 的行为一致
+func truncatePeriod(t time.Time, d time.Duration) time.Time {
+	t = t.In(time.Local)
+	if d > 24*time.Hour {
+		_, offset := t.Zone()
+		shift := time.Duration(offset) * time.Second
+		return t.Add(shift).Truncate(d).Add(-shift)
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	return midnight.Add(t.Sub(midnight) / d * d)
+}
+
+// open 打开当前日志文件，文件已存在时追加写入
+func (w *RotateWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file failed [%v]", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file failed [%v]", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.period = w.currentPeriod()
+	if w.options.RotationTime > 0 && info.Size() > 0 {
+		// 沿用已存在文件的周期，使重启后跨周期的文件也能被分割
+		w.period = truncatePeriod(info.ModTime(), w.options.RotationTime)
+	}
+
+	return nil
+}
+
+func (w *RotateWriter) rotate() error {
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+
+	err := os.Rename(w.path, w.backupPath(time.Now()))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rename log file failed [%v]", err)
+	}
+
+	err = w.open()
+	if err != nil {
+		return err
+	}
+
+	w.triggerMill()
+
+	return nil
+}
+
+// Rotate 立即分割当前日志文件
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Reopen 重新打开当前日志文件，用于配合 logrotate 等外部工具移动日志文件
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+
+	return w.open()
+}
+
+func (w *RotateWriter) Close() error {
+	unregisterRotateWriter(w)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	select {
+	case <-w.closed:
+		return nil
+	default:
+		close(w.closed)
+	}
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+func (w *RotateWriter) prefix() string {
+	return strings.TrimSuffix(filepath.Base(w.path), filepath.Ext(w.path)) + "-"
+}
+
+// backupPath 返回不与已有历史文件（包括已压缩的）重名的路径
+func (w *RotateWriter) backupPath(t time.Time) string {
+	base := filepath.Join(filepath.Dir(w.path), w.prefix()+t.Format(backupTimeFormat))
+	ext := filepath.Ext(w.path)
+
+	path := base + ext
+	for seq := 1; exists(path) || exists(path+compressSuffix); seq++ {
+		path = base + "-" + strconv.Itoa(seq) + ext
+	}
+
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func (w *RotateWriter) triggerMill() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *RotateWriter) millLoop() {
+	for {
+		select {
+		case <-w.millCh:
+			w.mill()
+		case <-w.closed:
+			return
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	seq  int
+	size int64
+}
+
+// backups 返回所有历史文件，按时间从新到旧排列
+func (w *RotateWriter) backups() []backupFile {
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil
+	}
+
+	var (
+		prefix = w.prefix()
+		ext    = filepath.Ext(w.path)
+		files  []backupFile
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		stamp, suffix, _ := strings.Cut(strings.TrimSuffix(ts, ext), "-")
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		var seq int
+		if suffix != "" {
+			seq, err = strconv.Atoi(suffix)
+			if err != nil {
+				continue
+			}
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, backupFile{
+			path: filepath.Join(filepath.Dir(w.path), name),
+			time: t,
+			seq:  seq,
+			size: info.Size(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].time.Equal(files[j].time) {
+			return files[i].seq > files[j].seq
+		}
+		return files[i].time.After(files[j].time)
+	})
+
+	return files
+}
+
+// mill 压缩历史文件，并删除超出保留策略的历史文件
+func (w *RotateWriter) mill() {
+	files := w.backups()
+
+	if w.options.Compress {
+		for i, file := range files {
+			if strings.HasSuffix(file.path, compressSuffix) {
+				continue
+			}
+			dst := file.path + compressSuffix
+			err := zcompress.GzipFile(file.path, dst)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Compress log file failed, path: %s, error: %v.\n", file.path, err)
+				continue
+			}
+			_ = os.Remove(file.path)
+			if info, err := os.Stat(dst); err == nil {
+				files[i].size = info.Size()
+			}
+			files[i].path = dst
+		}
+	}
+
+	var total int64
+	w.mu.Lock()
+	total = w.size
+	w.mu.Unlock()
+
+	for i, file := range files {
+		total += file.size
+		switch {
+		case w.options.MaxBackups > 0 && i >= w.options.MaxBackups:
+		case w.options.MaxAge > 0 && time.Since(file.time) > w.options.MaxAge:
+		case w.options.MaxTotalSize > 0 && total > w.options.MaxTotalSize:
+		default:
+			continue
+		}
+		_ = os.Remove(file.path)
+	}
+}
+
+var (
+	_rotateWritersMu sync.Mutex
+	_rotateWriters   = make(map[*RotateWriter]struct{})
+	_reopenOnce      sync.Once
+)
+
+func registerRotateWriter(w *RotateWriter) {
+	_rotateWritersMu.Lock()
+	_rotateWriters[w] = struct{}{}
+	_rotateWritersMu.Unlock()
+}
+
+func unregisterRotateWriter(w *RotateWriter) {
+	_rotateWritersMu.Lock()
+	delete(_rotateWriters, w)
+	_rotateWritersMu.Unlock()
+}
+
+// ReopenFiles 重新打开所有 RotateWriter 的日志文件
+func ReopenFiles() {
+	_rotateWritersMu.Lock()
+	writers := make([]*RotateWriter, 0, len(_rotateWriters))
+	for w := range _rotateWriters {
+		writers = append(writers, w)
+	}
+	_rotateWritersMu.Unlock()
+
+	for _, w := range writers {
+		err := w.Reopen()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Reopen log file failed, path: %s, error: %v.\n", w.path, err)
+		}
+	}
+}
+
+// ReopenOnSignal 收到 SIGHUP 信号时重新打开所有 RotateWriter 的日志文件，多次调用只生效一次
+func ReopenOnSignal() {
+	_reopenOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGHUP)
+		go func() {
+			for range ch {
+				ReopenFiles()
+			}
+		}()
+	})
+}
@@ -0,0 +1,113 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{MaxSize: 100, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 5; i++ {
+		_, err = w.Write(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.mill()
+
+	if n := len(w.backups()); n != 2 {
+		t.Errorf("expected 2 backups, got %d", n)
+	}
+
+	err = os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Reopen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(line)
+
+	info, err := os.Stat(filepath.Join(dir, "app.log"))
+	if err != nil || info.Size() != int64(len(line)) {
+		t.Errorf("log file should be reopened, error: %v", err)
+	}
+}
+
+func TestRotateSameMillisecond(t *testing.T) {
+	w, err := NewRotateWriter(filepath.Join(t.TempDir(), "app.log"), RotateOptions{MaxSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 同一毫秒内的分割不覆盖已有的历史文件
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		path := w.backupPath(now)
+		err = os.WriteFile(path, nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups := w.backups()
+	if len(backups) != 3 || backups[0].seq != 2 {
+		t.Errorf("backups: %v", backups)
+	}
+}
+
+func TestTruncatePeriodLocal(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	defer func() { time.Local = local }()
+
+	now := time.Date(2024, 1, 2, 7, 30, 0, 0, time.Local)
+	if got := truncatePeriod(now, 24*time.Hour); !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("24h period: %s", got)
+	}
+	if got := truncatePeriod(now, 6*time.Hour); !got.Equal(time.Date(2024, 1, 2, 6, 0, 0, 0, time.Local)) {
+		t.Errorf("6h period: %s", got)
+	}
+}
+
+func TestInitClosesReplaced(t *testing.T) {
+	config := Config{Path: t.TempDir(), Name: "app", MaxSize: 1, Async: true}
+
+	counts := func() (int, int) {
+		_rotateWritersMu.Lock()
+		defer _rotateWritersMu.Unlock()
+		_asyncWritersMu.Lock()
+		defer _asyncWritersMu.Unlock()
+		return len(_rotateWriters), len(_asyncWriters)
+	}
+
+	err := Init(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotates, asyncs := counts()
+
+	// 重新初始化时关闭被替换的日志的 RotateWriter 和 AsyncWriter
+	err = Init(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLogger(Default)
+
+	r, a := counts()
+	if r != rotates || a != asyncs {
+		t.Errorf("rotate writers: %d -> %d, async writers: %d -> %d", rotates, r, asyncs, a)
+	}
+}
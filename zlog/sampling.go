@@ -0,0 +1,273 @@
+package zlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+// SuppressedField 采样汇总日志中被抑制的日志条数的字段名，带有该字段的日志不会被采样
+const SuppressedField = "suppressed"
+
+// sampledKey 标记被抑制的日志，带有该标记的日志不会被格式化和投递
+const sampledKey = "zlog.sampled"
+
+// maxSamplingKeys 每个周期内最多统计的不同日志数量，超出后新日志不再采样
+const maxSamplingKeys = 10000
+
+// SamplingConfig 对重复日志采样，每个周期内相同等级、相似内容的日志先输出前 First 条，之后每 Thereafter 条输出 1 条，
+// 周期结束时输出一条被抑制的日志条数的汇总日志，数字不同的日志视为相似
+type SamplingConfig struct {
+	Interval time.Duration           // optional, 采样周期，默认为 1s
+	Levels   map[string]SamplingRule // optional, 键为日志等级，为空时不采样
+}
+
+type SamplingRule struct {
+	First      int // optional, 每个周期内输出的前几条日志
+	Thereafter int // optional, 超出 First 后每几条输出 1 条，为 0 时不再输出
+}
+
+func (c SamplingConfig) Enabled() bool {
+	return len(c.Levels) > 0
+}
+
+var _sampledTotal = zmetrics.NewCounter(
+	"zlog_sampled_entries_total",
+	"Number of log entries suppressed by sampling.",
+	"logger", "level",
+)
+
+type samplingKey struct {
+	level   logrus.Level
+	message string
+}
+
+type samplingCount struct {
+	n          int
+	suppressed int
+	message    string
+}
+
+// Sampler 对重复日志采样的 hook
+type Sampler struct {
+	name   string
+	logger *logrus.Logger
+	rules  map[logrus.Level]SamplingRule
+	levels []logrus.Level
+
+	mu     sync.Mutex
+	counts map[samplingKey]*samplingCount
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewSampler 创建 Sampler，汇总日志写入 logger，name 用于区分指标
+func NewSampler(name string, logger *logrus.Logger, config SamplingConfig) *Sampler {
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	s := &Sampler{
+		name:   name,
+		logger: logger,
+		rules:  make(map[logrus.Level]SamplingRule, len(config.Levels)),
+		counts: make(map[samplingKey]*samplingCount),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for name, rule := range config.Levels {
+		lvl := level(name)
+		s.rules[lvl] = rule
+		s.levels = append(s.levels, lvl)
+	}
+
+	go s.loop(interval)
+
+	registerSampler(s)
+
+	return s
+}
+
+func (s *Sampler) loop(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.rotate()
+		case <-s.stop:
+			s.rotate()
+			return
+		}
+	}
+}
+
+// Close 输出当前周期的汇总日志并停止后台 goroutine，关闭后不再采样
+func (s *Sampler) Close() {
+	unregisterSampler(s)
+
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *Sampler) Levels() []logrus.Level {
+	return s.levels
+}
+
+func (s *Sampler) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[SuppressedField]; ok {
+		return nil
+	}
+
+	rule, ok := s.rules[entry.Level]
+	if !ok {
+		return nil
+	}
+
+	key := samplingKey{level: entry.Level, message: normalizeMessage(entry.Message)}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	c, ok := s.counts[key]
+	if !ok {
+		if len(s.counts) >= maxSamplingKeys {
+			s.mu.Unlock()
+			return nil
+		}
+		c = &samplingCount{message: entry.Message}
+		s.counts[key] = c
+	}
+	c.n++
+	n := c.n - rule.First
+	suppressed := n > 0 && (rule.Thereafter <= 0 || n%rule.Thereafter != 0)
+	if suppressed {
+		c.suppressed++
+	}
+	s.mu.Unlock()
+
+	if suppressed {
+		entry.Data[sampledKey] = true
+		_sampledTotal.Inc(s.name, entry.Level.String())
+	}
+
+	return nil
+}
+
+// rotate 开始新的周期，并输出上个周期的汇总日志
+func (s *Sampler) rotate() {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = make(map[samplingKey]*samplingCount, len(counts))
+	s.mu.Unlock()
+
+	for key, c := range counts {
+		if c.suppressed == 0 {
+			continue
+		}
+		s.logger.WithField(SuppressedField, c.suppressed).
+			Log(key.level, fmt.Sprintf("Suppressed %d similar messages, sample: %s", c.suppressed, c.message))
+	}
+}
+
+// normalizeMessage 将连续的数字替换为 0，使只有数字不同的日志视为相似
+func normalizeMessage(message string) string {
+	var (
+		bs    = make([]byte, 0, len(message))
+		digit bool
+	)
+	for i := 0; i < len(message); i++ {
+		ch := message[i]
+		if ch >= '0' && ch <= '9' {
+			if !digit {
+				bs = append(bs, '0')
+			}
+			digit = true
+			continue
+		}
+		digit = false
+		bs = append(bs, ch)
+	}
+	return string(bs)
+}
+
+var (
+	_samplersMu sync.Mutex
+	_samplers   = make(map[*Sampler]struct{})
+)
+
+func registerSampler(s *Sampler) {
+	_samplersMu.Lock()
+	_samplers[s] = struct{}{}
+	_samplersMu.Unlock()
+}
+
+func unregisterSampler(s *Sampler) {
+	_samplersMu.Lock()
+	delete(_samplers, s)
+	_samplersMu.Unlock()
+}
+
+// closeSamplers 关闭写入 logger 的 Sampler，logger 为 nil 时关闭所有 Sampler
+func closeSamplers(logger *logrus.Logger) {
+	_samplersMu.Lock()
+	samplers := make([]*Sampler, 0, len(_samplers))
+	for s := range _samplers {
+		if logger == nil || s.logger == logger {
+			samplers = append(samplers, s)
+		}
+	}
+	_samplersMu.Unlock()
+
+	for _, s := range samplers {
+		s.Close()
+	}
+}
+
+// flushSamplers 输出所有 Sampler 当前周期的汇总日志，Sampler 继续采样
+func flushSamplers() {
+	_samplersMu.Lock()
+	samplers := make([]*Sampler, 0, len(_samplers))
+	for s := range _samplers {
+		samplers = append(samplers, s)
+	}
+	_samplersMu.Unlock()
+
+	for _, s := range samplers {
+		s.rotate()
+	}
+}
+
+func isSampled(entry *logrus.Entry) bool {
+	_, ok := entry.Data[sampledKey]
+	return ok
+}
+
+// samplingFormatter 不输出被采样抑制的日志
+type samplingFormatter struct {
+	logrus.Formatter
+}
+
+func (f samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if isSampled(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
@@ -0,0 +1,74 @@
+package zlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	config := Config{
+		Console: true,
+		Level:   "debug",
+		Sampling: SamplingConfig{
+			Interval: time.Hour,
+			Levels:   map[string]SamplingRule{"warn": {First: 2, Thereafter: 3}},
+		},
+	}
+
+	logger, err := NewConsoleLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	logger.AddHook(NewSampler("test", logger, config.Sampling))
+
+	for i := 0; i < 10; i++ {
+		logger.Warnf("Request failed, id: %d.", i)
+		logger.Infof("Request succeed, id: %d.", i)
+	}
+
+	// 前 2 条及之后的第 3、6 条
+	if n := strings.Count(buf.String(), "Request failed"); n != 4 {
+		t.Errorf("got %d warn lines, want 4:\n%s", n, buf.String())
+	}
+	if n := strings.Count(buf.String(), "Request succeed"); n != 10 {
+		t.Errorf("got %d info lines, want 10", n)
+	}
+
+	// Flush 输出汇总日志，之后继续采样
+	buf.Reset()
+	Flush(time.Second)
+
+	if !strings.Contains(buf.String(), "Suppressed 6 similar messages") || !strings.Contains(buf.String(), "suppressed=6") {
+		t.Errorf("unexpected summary: %s", buf.String())
+	}
+
+	buf.Reset()
+	for i := 0; i < 5; i++ {
+		logger.Warnf("Request failed, id: %d.", i)
+	}
+	if n := strings.Count(buf.String(), "Request failed"); n != 3 {
+		t.Errorf("got %d warn lines after flush, want 3", n)
+	}
+
+	// 退出前 Finally 关闭 Sampler 并输出汇总日志
+	buf.Reset()
+	Finally(time.Second)
+
+	if !strings.Contains(buf.String(), "Suppressed 2 similar messages") {
+		t.Errorf("unexpected summary: %s", buf.String())
+	}
+
+	// 关闭后不再采样
+	buf.Reset()
+	for i := 0; i < 5; i++ {
+		logger.Warnf("Request failed, id: %d.", i)
+	}
+	if n := strings.Count(buf.String(), "Request failed"); n != 5 {
+		t.Errorf("got %d warn lines after close, want 5", n)
+	}
+}
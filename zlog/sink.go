@@ -0,0 +1,533 @@
+package zlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+// 内置的日志投递类型，elastic 由 zelastic 包注册
+const (
+	SinkSyslog  = "syslog"
+	SinkHTTP    = "http"
+	SinkElastic = "elastic"
+)
+
+// SinkConfig 将日志投递到外部系统的配置，日志按批发送，失败时按指数退避重试，重试失败后写入本地溢出文件，
+// 目标恢复后重新发送溢出文件中的日志
+type SinkConfig struct {
+	Type            string            `valid:"required"` // must, 参考 SinkSyslog、SinkHTTP、SinkElastic 及通过 RegisterSender 注册的类型
+	Level           string            // optional, 投递的最低日志等级，默认投递所有等级
+	Network         string            `valid:"oneof=udp tcp"` // optional, syslog 的网络类型，默认为 udp
+	Address         string            // optional, syslog 的地址，如 127.0.0.1:514
+	Facility        int               // optional, syslog 的 facility，默认为 16（local0）
+	AppName         string            // optional, syslog 的 APP-NAME，默认为日志名称
+	URL             string            // optional, http 的接收地址，日志以 NDJSON 格式 POST 到该地址
+	Headers         map[string]string // optional, http 的请求头
+	ClientId        string            // optional, elastic 使用的 zelastic 客户端 id，默认为 default
+	Index           string            // optional, elastic 的索引前缀，按天生成 <Index>-2006.01.02 格式的索引，默认为日志名称
+	Timeout         time.Duration     // optional, 单次发送的超时时间，默认为 5s
+	BatchSize       int               // optional, 每批发送的最大日志条数，默认为 100
+	FlushInterval   time.Duration     // optional, 未满一批时的发送间隔，默认为 1s
+	BufferSize      int               // optional, 待发送日志的缓冲条数，缓冲区已满时写入溢出文件，默认为 10000
+	MaxRetries      int               // optional, 发送失败时的重试次数，默认为 3
+	RetryBackoff    time.Duration     // optional, 第一次重试前的等待时间，之后每次翻倍，默认为 500ms
+	RetryMaxBackoff time.Duration     // optional, 重试前的最长等待时间，默认为 30s
+	SpillPath       string            // optional, 溢出文件所在目录，默认为日志目录，为空时丢弃发送失败的日志
+	SpillMaxSize    int               // optional, 溢出文件的最大大小，单位 MB，超出后丢弃日志，默认为 100
+}
+
+func (c SinkConfig) Default(config Config) SinkConfig {
+	if c.Network == "" {
+		c.Network = "udp"
+	}
+	if c.Facility == 0 {
+		c.Facility = 16
+	}
+	if c.AppName == "" {
+		c.AppName = config.Name
+	}
+	if c.ClientId == "" {
+		c.ClientId = "default"
+	}
+	if c.Index == "" {
+		c.Index = config.Name
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 10000
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = 500 * time.Millisecond
+	}
+	if c.RetryMaxBackoff <= 0 {
+		c.RetryMaxBackoff = 30 * time.Second
+	}
+	if c.SpillPath == "" {
+		c.SpillPath = config.Path
+	}
+	if c.SpillMaxSize <= 0 {
+		c.SpillMaxSize = 100
+	}
+	return c
+}
+
+// Sender 将日志编码后按批发送到外部系统
+type Sender interface {
+	// Encode 将日志编码为一条待发送的记录
+	Encode(entry *logrus.Entry) ([]byte, error)
+	// Send 发送一批记录，只有部分记录发送失败时返回 *PartialError
+	Send(ctx context.Context, records [][]byte) error
+	Close() error
+}
+
+// PartialError 一批记录中只有部分发送失败，只重试 Records 中的记录
+type PartialError struct {
+	Records [][]byte
+	Err     error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d records send failed [%v]", len(e.Records), e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// SenderFactory 根据配置创建 Sender，config 已设置默认值
+type SenderFactory func(config SinkConfig, logConfig Config) (Sender, error)
+
+var (
+	_sendersMu sync.RWMutex
+	_senders   = map[string]SenderFactory{
+		SinkSyslog: newSyslogSender,
+		SinkHTTP:   newHTTPSender,
+	}
+)
+
+// RegisterSender 注册日志投递类型，需要在 Init 之前调用
+func RegisterSender(typ string, factory SenderFactory) {
+	_sendersMu.Lock()
+	_senders[typ] = factory
+	_sendersMu.Unlock()
+}
+
+var _sinkRecords = zmetrics.NewCounter(
+	"zlog_sink_records_total",
+	"Number of log records handled by sinks, by result.",
+	"sink", "result",
+)
+
+// 记录的处理结果
+const (
+	sinkSent    = "sent"
+	sinkSpilled = "spilled"
+	sinkDropped = "dropped"
+)
+
+// Sink 将日志异步投递到外部系统的 hook
+type Sink struct {
+	name   string
+	config SinkConfig
+	sender Sender
+	levels []logrus.Level
+
+	queue   chan []byte
+	flushCh chan chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	spillMu   sync.Mutex
+	spillFile string
+	spilled   bool // 溢出文件中可能有待发送的记录
+	lastRetry time.Time
+}
+
+// NewSink 创建 Sink，name 用于区分指标和溢出文件
+func NewSink(name string, config SinkConfig, logConfig Config) (*Sink, error) {
+	config = config.Default(logConfig)
+
+	_sendersMu.RLock()
+	factory, ok := _senders[config.Type]
+	_sendersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log sink type [%s]", config.Type)
+	}
+
+	sender, err := factory(config, logConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create log sink [%s] failed [%v]", config.Type, err)
+	}
+
+	s := &Sink{
+		name:    name + "-" + config.Type,
+		config:  config,
+		sender:  sender,
+		levels:  logrus.AllLevels,
+		queue:   make(chan []byte, config.BufferSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if config.Level != "" {
+		s.levels = logrus.AllLevels[:level(config.Level)+1]
+	}
+	if config.SpillPath != "" {
+		err = os.MkdirAll(config.SpillPath, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("create spill dir failed [%v]", err)
+		}
+		s.spillFile = filepath.Join(config.SpillPath, s.name+".spill")
+		s.spilled = true
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	go s.loop()
+
+	registerSink(s)
+
+	return s, nil
+}
+
+func (s *Sink) Levels() []logrus.Level {
+	return s.levels
+}
+
+func (s *Sink) Fire(entry *logrus.Entry) error {
+	if isSampled(entry) {
+		return nil
+	}
+
+	record, err := s.sender.Encode(entry)
+	if err != nil {
+		return err
+	}
+
+	if s.ctx.Err() != nil {
+		s.spill([][]byte{record})
+		return nil
+	}
+
+	select {
+	case s.queue <- record:
+	default:
+		// 缓冲区已满时不阻塞记录日志，直接写入溢出文件
+		s.spill([][]byte{record})
+	}
+
+	return nil
+}
+
+func (s *Sink) loop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.config.BatchSize)
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.send(batch)
+				batch = batch[:0]
+			}
+			s.replay(false)
+		case reply := <-s.flushCh:
+			batch = s.drain(batch)
+			close(reply)
+		case <-s.ctx.Done():
+			s.drain(batch)
+			return
+		}
+	}
+}
+
+// drain 发送缓冲区中的所有记录
+func (s *Sink) drain(batch [][]byte) [][]byte {
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		default:
+			if len(batch) > 0 {
+				s.send(batch)
+			}
+			return batch[:0]
+		}
+	}
+}
+
+// send 发送一批记录，重试失败后写入溢出文件
+func (s *Sink) send(batch [][]byte) {
+	records := batch
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.sendOnce(records)
+		if err == nil {
+			_sinkRecords.Add(float64(len(records)), s.name, sinkSent)
+			s.replay(true)
+			return
+		}
+
+		var partial *PartialError
+		if errors.As(err, &partial) {
+			_sinkRecords.Add(float64(len(records)-len(partial.Records)), s.name, sinkSent)
+			records = partial.Records
+		}
+
+		if attempt >= s.config.MaxRetries || s.ctx.Err() != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Send logs failed, sink: %s, error: %v.\n", s.name, err)
+			s.spill(records)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+		}
+		backoff *= 2
+		if backoff > s.config.RetryMaxBackoff {
+			backoff = s.config.RetryMaxBackoff
+		}
+	}
+}
+
+func (s *Sink) sendOnce(records [][]byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	return s.sender.Send(ctx, records)
+}
+
+// spill 将记录追加到溢出文件，每行是一条 JSON 字符串编码的记录
+func (s *Sink) spill(records [][]byte) {
+	if s.spillFile == "" {
+		_sinkRecords.Add(float64(len(records)), s.name, sinkDropped)
+		return
+	}
+
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	if info, err := os.Stat(s.spillFile); err == nil && info.Size() >= int64(s.config.SpillMaxSize)*megabyte {
+		_sinkRecords.Add(float64(len(records)), s.name, sinkDropped)
+		return
+	}
+
+	file, err := os.OpenFile(s.spillFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Open spill file failed, path: %s, error: %v.\n", s.spillFile, err)
+		_sinkRecords.Add(float64(len(records)), s.name, sinkDropped)
+		return
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, record := range records {
+		bs, _ := json.Marshal(string(record))
+		_, _ = w.Write(bs)
+		_ = w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Write spill file failed, path: %s, error: %v.\n", s.spillFile, err)
+		_sinkRecords.Add(float64(len(records)), s.name, sinkDropped)
+		return
+	}
+
+	s.spilled = true
+	_sinkRecords.Add(float64(len(records)), s.name, sinkSpilled)
+}
+
+// spillReplayInterval 没有发送成功的记录时，重新发送溢出文件的间隔
+const spillReplayInterval = 30 * time.Second
+
+// replay 重新发送溢出文件中的记录，recovered 为 true 表示刚刚发送成功
+func (s *Sink) replay(recovered bool) {
+	if s.spillFile == "" {
+		return
+	}
+	if !recovered && time.Since(s.lastRetry) < spillReplayInterval {
+		return
+	}
+	s.lastRetry = time.Now()
+
+	replayFile := s.spillFile + ".replay"
+
+	// 上次重新发送中断时遗留的文件优先发送
+	s.spillMu.Lock()
+	if !s.spilled {
+		s.spillMu.Unlock()
+		return
+	}
+	s.spilled = false
+	_, err := os.Stat(replayFile)
+	if os.IsNotExist(err) {
+		err = os.Rename(s.spillFile, replayFile)
+	}
+	s.spillMu.Unlock()
+	if err != nil {
+		return
+	}
+
+	file, err := os.Open(replayFile)
+	if err != nil {
+		return
+	}
+
+	var (
+		records [][]byte
+		failed  bool
+		scanner = bufio.NewScanner(file)
+	)
+	scanner.Buffer(make([]byte, 64*1024), 16*megabyte)
+
+	flush := func() {
+		if len(records) == 0 {
+			return
+		}
+		if failed {
+			s.spill(records)
+		} else if err := s.sendOnce(records); err != nil {
+			// 目标仍不可用，剩余记录全部写回溢出文件
+			failed = true
+			var partial *PartialError
+			if errors.As(err, &partial) {
+				_sinkRecords.Add(float64(len(records)-len(partial.Records)), s.name, sinkSent)
+				records = partial.Records
+			}
+			s.spill(records)
+		} else {
+			_sinkRecords.Add(float64(len(records)), s.name, sinkSent)
+		}
+		records = nil
+	}
+
+	for scanner.Scan() {
+		var record string
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		records = append(records, []byte(record))
+		if len(records) >= s.config.BatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	_ = file.Close()
+	_ = os.Remove(replayFile)
+}
+
+// Flush 发送缓冲区中的所有记录，最多等待 timeout，全部处理完成时返回 true
+func (s *Sink) Flush(timeout time.Duration) bool {
+	reply := make(chan struct{})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case s.flushCh <- reply:
+	case <-s.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+
+	select {
+	case <-reply:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Close 发送缓冲区中的所有记录后停止后台 goroutine，之后的日志直接写入溢出文件
+func (s *Sink) Close() error {
+	unregisterSink(s)
+
+	s.cancel()
+	<-s.done
+
+	return s.sender.Close()
+}
+
+var (
+	_sinksMu sync.Mutex
+	_sinks   = make(map[*Sink]struct{})
+)
+
+func registerSink(s *Sink) {
+	_sinksMu.Lock()
+	_sinks[s] = struct{}{}
+	_sinksMu.Unlock()
+}
+
+func unregisterSink(s *Sink) {
+	_sinksMu.Lock()
+	delete(_sinks, s)
+	_sinksMu.Unlock()
+}
+
+func flushSinks(deadline time.Time) bool {
+	_sinksMu.Lock()
+	sinks := make([]*Sink, 0, len(_sinks))
+	for s := range _sinks {
+		sinks = append(sinks, s)
+	}
+	_sinksMu.Unlock()
+
+	ok := true
+	for _, s := range sinks {
+		if !s.Flush(time.Until(deadline)) {
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// addSinks 为 logger 添加 config 中配置的所有 Sink
+func addSinks(logger *logrus.Logger, config Config, closers *[]func()) error {
+	for _, sc := range config.Sinks {
+		sink, err := NewSink(config.Name, sc, config)
+		if err != nil {
+			return err
+		}
+		addCloser(closers, func() { _ = sink.Close() })
+		logger.AddHook(sink)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// httpSender 将日志以 NDJSON 格式 POST 到指定地址
+type httpSender struct {
+	url       string
+	headers   map[string]string
+	client    *http.Client
+	formatter logrus.Formatter
+}
+
+func newHTTPSender(config SinkConfig, _ Config) (Sender, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("http url is empty")
+	}
+
+	return &httpSender{
+		url:     config.URL,
+		headers: config.Headers,
+		client:  &http.Client{},
+		formatter: &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+		},
+	}, nil
+}
+
+func (s *httpSender) Encode(entry *logrus.Entry) ([]byte, error) {
+	return s.formatter.Format(entry)
+}
+
+func (s *httpSender) Send(ctx context.Context, records [][]byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(bytes.Join(records, nil)))
+	if err != nil {
+		return fmt.Errorf("new request error [%v]", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request error [%v]", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("response status [%d]", res.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *httpSender) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
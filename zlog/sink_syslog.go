@@ -0,0 +1,130 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogSender 按 RFC5424 格式发送日志，tcp 使用 RFC6587 的 octet counting 分帧
+type syslogSender struct {
+	network   string
+	address   string
+	facility  int
+	appName   string
+	hostname  string
+	procId    string
+	formatter logrus.Formatter
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogSender(config SinkConfig, logConfig Config) (Sender, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("syslog address is empty")
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogSender{
+		network:   config.Network,
+		address:   config.Address,
+		facility:  config.Facility,
+		appName:   config.AppName,
+		hostname:  hostname,
+		procId:    strconv.Itoa(os.Getpid()),
+		formatter: formatter(logConfig),
+	}, nil
+}
+
+// syslogSeverity 将日志等级转换为 syslog 的 severity
+func syslogSeverity(lvl logrus.Level) int {
+	switch lvl {
+	case logrus.PanicLevel:
+		return 0 // emerg
+	case logrus.FatalLevel:
+		return 2 // crit
+	case logrus.ErrorLevel:
+		return 3 // err
+	case logrus.WarnLevel:
+		return 4 // warning
+	case logrus.InfoLevel:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// Encode 编码为 <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *syslogSender) Encode(entry *logrus.Entry) ([]byte, error) {
+	msg, err := s.formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	pri := s.facility*8 + syslogSeverity(entry.Level)
+	header := fmt.Sprintf("<%d>1 %s %s %s %s - - ",
+		pri, entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, s.appName, s.procId)
+
+	return append([]byte(header), bytes.TrimRight(msg, "\n")...), nil
+}
+
+func (s *syslogSender) Send(ctx context.Context, records [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		dialer := net.Dialer{}
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("dial syslog failed [%v]", err)
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	} else {
+		_ = s.conn.SetWriteDeadline(time.Time{})
+	}
+
+	for i, record := range records {
+		var err error
+		if s.network == "tcp" {
+			_, err = fmt.Fprintf(s.conn, "%d %s", len(record), record)
+		} else {
+			_, err = s.conn.Write(record)
+		}
+		if err != nil {
+			_ = s.conn.Close()
+			s.conn = nil
+			return &PartialError{Records: records[i:], Err: fmt.Errorf("write syslog failed [%v]", err)}
+		}
+	}
+
+	return nil
+}
+
+func (s *syslogSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
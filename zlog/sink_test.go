@@ -0,0 +1,103 @@
+package zlog
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHTTPSinkSpillAndReplay(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+		down  atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		bs, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		scanner := bufio.NewScanner(strings.NewReader(string(bs)))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	sink, err := NewSink("test", SinkConfig{
+		Type:          SinkHTTP,
+		URL:           server.URL,
+		FlushInterval: time.Hour,
+		MaxRetries:    1,
+		RetryBackoff:  time.Millisecond,
+		SpillPath:     dir,
+	}, Config{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(sink)
+
+	down.Store(true)
+	logger.Info("m1")
+	logger.Info("m2")
+	sink.Flush(time.Second)
+
+	bs, err := os.ReadFile(filepath.Join(dir, "test-http.spill"))
+	if err != nil || strings.Count(string(bs), "\n") != 2 {
+		t.Fatalf("spill file: %q, error: %v", bs, err)
+	}
+
+	down.Store(false)
+	logger.Info("m3")
+	sink.Flush(time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 3 {
+		t.Fatalf("received %d lines, want 3: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], `"msg":"m3"`) || !strings.Contains(lines[2], `"msg":"m2"`) {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test-http.spill")); !os.IsNotExist(err) {
+		t.Errorf("spill file not removed: %v", err)
+	}
+}
+
+func TestSyslogEncode(t *testing.T) {
+	sender, err := newSyslogSender(SinkConfig{Address: "127.0.0.1:514", Facility: 16, AppName: "app"}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.WarnLevel
+	entry.Time = time.Date(2024, 4, 2, 19, 27, 31, 0, time.UTC)
+	entry.Message = "hello"
+
+	bs, err := sender.Encode(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(bs), "<132>1 2024-04-02T19:27:31.000000Z ") || !strings.Contains(string(bs), " app ") {
+		t.Errorf("unexpected syslog message: %s", bs)
+	}
+}
@@ -0,0 +1,184 @@
+package zmetrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets 默认的直方图分桶，单位为秒
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// 标签值之间的分隔符，不会出现在正常的标签值中
+const labelSeparator = "\xff"
+
+type series struct {
+	values []string  // 标签值
+	value  float64   // counter、gauge 的值
+	counts []uint64  // histogram 各分桶的计数，不累加
+	sum    float64   // histogram 观测值之和
+	count  uint64    // histogram 观测次数
+	bucket []float64 // histogram 分桶上界
+}
+
+// metric 同名指标的所有序列
+type metric struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newMetric(name string, help string, typ string, labels []string, buckets []float64) *metric {
+	return &metric{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series, 8),
+	}
+}
+
+// get 返回标签值对应的序列，调用前必须加锁
+func (m *metric) get(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", m.name, len(m.labels), len(values)))
+	}
+
+	key := strings.Join(values, labelSeparator)
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if m.typ == TypeHistogram {
+			s.bucket = m.buckets
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+
+	return s
+}
+
+func (m *metric) delete(values []string) {
+	m.mu.Lock()
+	delete(m.series, strings.Join(values, labelSeparator))
+	m.mu.Unlock()
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	m *metric
+}
+
+// Inc 计数加 1，values 为标签值，顺序与创建时的标签一致
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 计数加 v，v 不能为负数
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", c.m.name))
+	}
+	c.m.mu.Lock()
+	c.m.get(values).value += v
+	c.m.mu.Unlock()
+}
+
+// Set 将计数设置为 v，用于在 collector 中输出外部累计的计数，如连接池的统计，只有外部计数重置时 v 才会减小
+func (c *Counter) Set(v float64, values ...string) {
+	c.m.mu.Lock()
+	c.m.get(values).value = v
+	c.m.mu.Unlock()
+}
+
+// Delete 删除标签值对应的序列
+func (c *Counter) Delete(values ...string) {
+	c.m.delete(values)
+}
+
+// Gauge 可增可减的数值
+type Gauge struct {
+	m *metric
+}
+
+func (g *Gauge) Set(v float64, values ...string) {
+	g.m.mu.Lock()
+	g.m.get(values).value = v
+	g.m.mu.Unlock()
+}
+
+func (g *Gauge) Add(v float64, values ...string) {
+	g.m.mu.Lock()
+	g.m.get(values).value += v
+	g.m.mu.Unlock()
+}
+
+func (g *Gauge) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+func (g *Gauge) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+// Delete 删除标签值对应的序列
+func (g *Gauge) Delete(values ...string) {
+	g.m.delete(values)
+}
+
+// Histogram 按分桶统计观测值的分布
+type Histogram struct {
+	m *metric
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+
+	s := h.m.get(values)
+	for i, upper := range s.bucket {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Delete 删除标签值对应的序列
+func (h *Histogram) Delete(values ...string) {
+	h.m.delete(values)
+}
+
+func checkBuckets(name string, buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	if math.IsInf(buckets[len(buckets)-1], 1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] == buckets[i-1] {
+			panic(fmt.Sprintf("histogram %s has duplicate bucket %v", name, buckets[i]))
+		}
+	}
+	return buckets
+}
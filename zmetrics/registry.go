@@ -0,0 +1,258 @@
+package zmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var _nameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Registry 保存所有指标，并以 Prometheus 文本格式输出
+type Registry struct {
+	mu         sync.RWMutex
+	metrics    map[string]*metric
+	collectors map[string]func()
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics:    make(map[string]*metric, 32),
+		collectors: make(map[string]func(), 8),
+	}
+}
+
+// register 注册指标，同名同类型的指标已存在时返回已存在的指标
+func (r *Registry) register(m *metric) *metric {
+	if !_nameRegexp.MatchString(m.name) {
+		panic(fmt.Sprintf("invalid metric name %s", m.name))
+	}
+	for _, label := range m.labels {
+		if !_nameRegexp.MatchString(label) || strings.Contains(label, ":") || label == "le" {
+			panic(fmt.Sprintf("invalid label name %s of metric %s", label, m.name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.metrics[m.name]; ok {
+		if old.typ != m.typ || strings.Join(old.labels, ",") != strings.Join(m.labels, ",") {
+			panic(fmt.Sprintf("metric %s already registered with different type or labels", m.name))
+		}
+		return old
+	}
+	r.metrics[m.name] = m
+
+	return m
+}
+
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{m: r.register(newMetric(name, help, TypeCounter, labels, nil))}
+}
+
+func (r *Registry) NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{m: r.register(newMetric(name, help, TypeGauge, labels, nil))}
+}
+
+// NewHistogram 创建直方图，buckets 为空时使用 DefaultBuckets
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{m: r.register(newMetric(name, help, TypeHistogram, labels, checkBuckets(name, buckets)))}
+}
+
+// Unregister 删除指标
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.metrics, name)
+	r.mu.Unlock()
+}
+
+// RegisterCollector 注册采集函数，每次输出指标前调用，用于更新连接池状态等需要实时读取的 gauge，同名采集函数会被替换
+func (r *Registry) RegisterCollector(name string, collect func()) {
+	r.mu.Lock()
+	r.collectors[name] = collect
+	r.mu.Unlock()
+}
+
+// UnregisterCollector 删除采集函数
+func (r *Registry) UnregisterCollector(name string) {
+	r.mu.Lock()
+	delete(r.collectors, name)
+	r.mu.Unlock()
+}
+
+// Write 以 Prometheus 文本格式输出所有指标，没有序列的指标不输出
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	collectors := make([]func(), 0, len(r.collectors))
+	for _, collect := range r.collectors {
+		collectors = append(collectors, collect)
+	}
+	r.mu.RUnlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+
+	r.mu.RLock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name < metrics[j].name
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		writeMetric(bw, m)
+	}
+
+	return bw.Flush()
+}
+
+// Handler 返回输出所有指标的 http.Handler
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+func writeMetric(w *bufio.Writer, m *metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.series) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if m.help != "" {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	}
+	_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+
+	for _, key := range keys {
+		s := m.series[key]
+		if m.typ != TypeHistogram {
+			writeSample(w, m.name, m.labels, s.values, "", s.value)
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range s.bucket {
+			cumulative += s.counts[i]
+			writeSample(w, m.name+"_bucket", m.labels, s.values, formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, m.name+"_bucket", m.labels, s.values, "+Inf", float64(s.count))
+		writeSample(w, m.name+"_sum", m.labels, s.values, "", s.sum)
+		writeSample(w, m.name+"_count", m.labels, s.values, "", float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels []string, values []string, le string, value float64) {
+	_, _ = w.WriteString(name)
+
+	if len(labels) > 0 || le != "" {
+		_ = w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(w, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		if le != "" {
+			if len(labels) > 0 {
+				_ = w.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(w, `le="%s"`, le)
+		}
+		_ = w.WriteByte('}')
+	}
+
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(formatFloat(value))
+	_ = w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	_helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	_labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return _helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return _labelEscaper.Replace(s)
+}
+
+var _default = NewRegistry()
+
+func Default() *Registry {
+	return _default
+}
+
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return _default.NewCounter(name, help, labels...)
+}
+
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return _default.NewGauge(name, help, labels...)
+}
+
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	return _default.NewHistogram(name, help, buckets, labels...)
+}
+
+func Unregister(name string) {
+	_default.Unregister(name)
+}
+
+func RegisterCollector(name string, collect func()) {
+	_default.RegisterCollector(name, collect)
+}
+
+func UnregisterCollector(name string) {
+	_default.UnregisterCollector(name)
+}
+
+func Write(w io.Writer) error {
+	return _default.Write(w)
+}
+
+func Handler() http.Handler {
+	return _default.Handler()
+}
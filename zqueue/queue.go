@@ -8,9 +8,15 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/yyliziqiu/zlib/zmetrics"
 	"github.com/yyliziqiu/zlib/zsnap"
 )
 
+var (
+	_lengthGauge   = zmetrics.NewGauge("zqueue_length", "Number of items in the queue.", "name")
+	_capacityGauge = zmetrics.NewGauge("zqueue_capacity", "Capacity of the queue.", "name")
+)
+
 var (
 	EmptyError           = errors.New("queue is empty")
 	ItemNotFoundError    = errors.New("item not found")
@@ -261,6 +267,26 @@ func (q *Queue) SetLogger(logger *logrus.Logger) *Queue {
 	return q
 }
 
+// EnableMetrics 以 name 为标签输出队列长度和容量指标
+func (q *Queue) EnableMetrics(name string) *Queue {
+	zmetrics.RegisterCollector("queue."+name, func() {
+		q.mu.RLock()
+		length, capacity := q.len(), q.cap()
+		q.mu.RUnlock()
+		_lengthGauge.Set(float64(length), name)
+		_capacityGauge.Set(float64(capacity), name)
+	})
+	return q
+}
+
+// DisableMetrics 停止输出 EnableMetrics 开启的指标
+func (q *Queue) DisableMetrics(name string) *Queue {
+	zmetrics.UnregisterCollector("queue." + name)
+	_lengthGauge.Delete(name)
+	_capacityGauge.Delete(name)
+	return q
+}
+
 // Get 获取指定下标的元素
 func (q *Queue) Get(i int) (interface{}, error) {
 	q.mu.RLock()
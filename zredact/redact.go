@@ -0,0 +1,211 @@
+package zredact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask 用于替换敏感内容的字符串
+const Mask = "******"
+
+var (
+	DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+	DefaultKeys = []string{"password", "passwd", "pwd", "secret", "client_secret", "token", "access_token", "refresh_token", "id_card", "phone", "mobile"}
+
+	DefaultPatterns = []string{
+		`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`, // bearer token
+	}
+
+	// PIIPatterns 身份证号和手机号，会同时匹配订单号、雪花 ID 等长数字，默认不启用，需要时追加到 Config.Patterns
+	PIIPatterns = []string{
+		`\b\d{17}[\dXx]\b`, // 身份证号
+		`\b1[3-9]\d{9}\b`,  // 手机号
+	}
+)
+
+type Config struct {
+	Headers  []string // optional, 需要脱敏的请求头和响应头，不区分大小写
+	Keys     []string // optional, 需要脱敏的 JSON 键和表单键，不区分大小写
+	Patterns []string // optional, 需要脱敏的内容的正则表达式
+	Mask     string   // optional, 默认为 Mask
+}
+
+func (c Config) Default() Config {
+	if c.Mask == "" {
+		c.Mask = Mask
+	}
+	return c
+}
+
+// Redactor 按请求头、键名和正则表达式对日志内容脱敏
+type Redactor struct {
+	mask     string
+	headers  map[string]bool
+	keys     map[string]bool
+	jsonStr  *regexp.Regexp // "key": "value"
+	jsonVal  *regexp.Regexp // "key": 123
+	formVal  *regexp.Regexp // key=value
+	patterns []*regexp.Regexp
+}
+
+func New(config Config) (*Redactor, error) {
+	config = config.Default()
+
+	r := &Redactor{
+		mask:    config.Mask,
+		headers: make(map[string]bool, len(config.Headers)),
+		keys:    make(map[string]bool, len(config.Keys)),
+	}
+
+	for _, header := range config.Headers {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	if len(config.Keys) > 0 {
+		quoted := make([]string, 0, len(config.Keys))
+		for _, key := range config.Keys {
+			r.keys[strings.ToLower(key)] = true
+			quoted = append(quoted, regexp.QuoteMeta(key))
+		}
+		keys := strings.Join(quoted, "|")
+		r.jsonStr = regexp.MustCompile(`("(?i:` + keys + `)"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+		r.jsonVal = regexp.MustCompile(`("(?i:` + keys + `)"\s*:\s*)(-?\d[\d.eE+\-]*|true|false)`)
+		r.formVal = regexp.MustCompile(`((?:^|[?&\s,;])(?i:` + keys + `)=)([^&\s,;"]*)`)
+	}
+
+	for _, pattern := range config.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile redact pattern [%s] failed [%v]", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+func MustNew(config Config) *Redactor {
+	r, err := New(config)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Header 返回脱敏后的请求头副本
+func (r *Redactor) Header(header http.Header) http.Header {
+	if r == nil || len(header) == 0 {
+		return header
+	}
+
+	result := make(http.Header, len(header))
+	for key, values := range header {
+		if r.headers[http.CanonicalHeaderKey(key)] {
+			result[key] = []string{r.mask}
+		} else {
+			result[key] = values
+		}
+	}
+
+	return result
+}
+
+// IsKey 是否为需要脱敏的键
+func (r *Redactor) IsKey(key string) bool {
+	return r != nil && r.keys[strings.ToLower(key)]
+}
+
+// String 对 JSON 或文本内容脱敏，保留原有格式
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	if r.jsonStr != nil {
+		s = r.jsonStr.ReplaceAllString(s, "${1}"+r.mask+"${3}")
+		s = r.jsonVal.ReplaceAllString(s, `${1}"`+r.mask+`"`)
+		s = r.formVal.ReplaceAllString(s, "${1}"+r.mask)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+
+	return s
+}
+
+// Bytes 与 String 相同
+func (r *Redactor) Bytes(bs []byte) []byte {
+	if r == nil || len(bs) == 0 {
+		return bs
+	}
+	return []byte(r.String(string(bs)))
+}
+
+// Value 将 v 编码为 JSON 后脱敏，无法编码时使用 %v 格式
+func (r *Redactor) Value(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return r.String(fmt.Sprintf("%v", v))
+	}
+	return r.String(string(bs))
+}
+
+// Hook 对日志内容和字段脱敏的 logrus hook，可通过 zlog.AddHook 添加
+type Hook struct {
+	Redactor *Redactor
+}
+
+func NewHook(r *Redactor) Hook {
+	return Hook{Redactor: r}
+}
+
+func (h Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h Hook) Fire(entry *logrus.Entry) error {
+	r := h.Redactor
+	if r == nil {
+		r = Default()
+	}
+
+	entry.Message = r.String(entry.Message)
+	for key, value := range entry.Data {
+		if r.IsKey(key) {
+			entry.Data[key] = r.mask
+			continue
+		}
+		if s, ok := value.(string); ok {
+			entry.Data[key] = r.String(s)
+		}
+	}
+
+	return nil
+}
+
+var _default atomic.Pointer[Redactor]
+
+func init() {
+	_default.Store(MustNew(Config{
+		Headers:  DefaultHeaders,
+		Keys:     DefaultKeys,
+		Patterns: DefaultPatterns,
+	}))
+}
+
+// Default 返回默认的 Redactor，zhttp 和 zweb 记录日志时使用
+func Default() *Redactor {
+	return _default.Load()
+}
+
+// SetDefault 替换默认的 Redactor
+func SetDefault(r *Redactor) {
+	_default.Store(r)
+}
@@ -0,0 +1,81 @@
+package zredact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactorString(t *testing.T) {
+	r := Default()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"name":"tom","password":"123\"456"}`, `{"name":"tom","password":"******"}`},
+		{`{"Token": "abc", "age": 18}`, `{"Token": "******", "age": 18}`},
+		{`{"phone": 13800138000}`, `{"phone": "******"}`},
+		{`a=1&token=abc&b=2`, `a=1&token=******&b=2`},
+		{`url: /login?pwd=abc, cost: 1s`, `url: /login?pwd=******, cost: 1s`},
+		{`Authorization: Bearer eyJhbGciOi.xx-yy`, `Authorization: ******`},
+		{`order: 202401021234567890, id: 1234567890123456789`, `order: 202401021234567890, id: 1234567890123456789`},
+		{`tokens=1`, `tokens=1`},
+	}
+
+	for _, tt := range tests {
+		if got := r.String(tt.in); got != tt.want {
+			t.Errorf("String(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	pii := MustNew(Config{Patterns: PIIPatterns})
+	if got := pii.String(`id: 11010519491231002X, tel: 13800138000`); got != `id: ******, tel: ******` {
+		t.Errorf("PII not redacted: %s", got)
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	r := MustNew(Config{Headers: []string{"authorization", "x-secret"}})
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic dG9tOjEyMw==")
+	header.Set("X-Secret", "abc")
+	header.Set("Content-Type", "application/json")
+
+	got := r.Header(header)
+	if got.Get("Authorization") != Mask || got.Get("X-Secret") != Mask {
+		t.Errorf("header not redacted: %v", got)
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("header redacted unexpectedly: %v", got)
+	}
+	if header.Get("Authorization") == Mask {
+		t.Error("original header modified")
+	}
+}
+
+func TestHook(t *testing.T) {
+	var buf strings.Builder
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.AddHook(NewHook(nil))
+
+	logger.WithField("password", "p@ss").WithField("body", `{"token":"abc"}`).Info("login phone=13800138000")
+
+	out := buf.String()
+	for _, s := range []string{"p@ss", "abc", "13800138000"} {
+		if strings.Contains(out, s) {
+			t.Errorf("log not redacted: %s", out)
+		}
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	_, err := New(Config{Patterns: []string{"("}})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
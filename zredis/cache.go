@@ -0,0 +1,36 @@
+package zredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Cache 以字节读写 Redis 的简单缓存，可用作 zhttp.TokenCache，用于多个实例共享令牌
+type Cache struct {
+	cli    redis.Cmdable
+	prefix string
+}
+
+// NewCache cli 可以是 *redis.Client 或 *redis.ClusterClient，prefix 为键的前缀
+func NewCache(cli redis.Cmdable, prefix string) *Cache {
+	return &Cache{
+		cli:    cli,
+		prefix: prefix,
+	}
+}
+
+// Get 键不存在时返回 nil, nil
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	bs, err := c.cli.Get(ctx, c.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return bs, err
+}
+
+// Set ttl 为 0 时键不过期
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.cli.Set(ctx, c.prefix+key, value, ttl).Err()
+}
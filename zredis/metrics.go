@@ -0,0 +1,44 @@
+package zredis
+
+import (
+	"github.com/go-redis/redis/v8"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+var (
+	_hits       = zmetrics.NewCounter("zredis_pool_hits_total", "Number of times a free connection was found in the pool.", "id")
+	_misses     = zmetrics.NewCounter("zredis_pool_misses_total", "Number of times a free connection was not found in the pool.", "id")
+	_timeouts   = zmetrics.NewCounter("zredis_pool_timeouts_total", "Number of times a wait timeout occurred.", "id")
+	_totalConns = zmetrics.NewGauge("zredis_pool_total_connections", "Number of connections in the pool.", "id")
+	_idleConns  = zmetrics.NewGauge("zredis_pool_idle_connections", "Number of idle connections in the pool.", "id")
+	_staleConns = zmetrics.NewCounter("zredis_pool_stale_connections_total", "Number of stale connections removed from the pool.", "id")
+)
+
+var (
+	_poolGauges   = []*zmetrics.Gauge{_totalConns, _idleConns}
+	_poolCounters = []*zmetrics.Counter{_hits, _misses, _timeouts, _staleConns}
+)
+
+// registerMetrics 在每次输出指标时读取连接池状态
+func registerMetrics(id string, poolStats func() *redis.PoolStats) {
+	zmetrics.RegisterCollector("redis."+id, func() {
+		stats := poolStats()
+		_hits.Set(float64(stats.Hits), id)
+		_misses.Set(float64(stats.Misses), id)
+		_timeouts.Set(float64(stats.Timeouts), id)
+		_totalConns.Set(float64(stats.TotalConns), id)
+		_idleConns.Set(float64(stats.IdleConns), id)
+		_staleConns.Set(float64(stats.StaleConns), id)
+	})
+}
+
+func unregisterMetrics(id string) {
+	zmetrics.UnregisterCollector("redis." + id)
+	for _, gauge := range _poolGauges {
+		gauge.Delete(id)
+	}
+	for _, counter := range _poolCounters {
+		counter.Delete(id)
+	}
+}
@@ -1,7 +1,11 @@
 package zredis
 
 import (
+	"context"
+
 	"github.com/go-redis/redis/v8"
+
+	"github.com/yyliziqiu/zlib/zhealth"
 )
 
 var (
@@ -23,9 +27,21 @@ func Init(configs ...Config) error {
 		cli, clu := New(config)
 		if cli != nil {
 			_clis[config.Id] = cli
+			zhealth.Register(zhealth.Probe{
+				Name:  "redis." + config.Id,
+				Level: config.HealthLevel,
+				Check: func(ctx context.Context) error { return cli.Ping(ctx).Err() },
+			})
+			registerMetrics(config.Id, cli.PoolStats)
 		}
 		if clu != nil {
 			_clus[config.Id] = clu
+			zhealth.Register(zhealth.Probe{
+				Name:  "redis." + config.Id,
+				Level: config.HealthLevel,
+				Check: func(ctx context.Context) error { return clu.Ping(ctx).Err() },
+			})
+			registerMetrics(config.Id, clu.PoolStats)
 		}
 	}
 
@@ -154,10 +170,14 @@ func NewFailoverClusterClient(config Config) *redis.ClusterClient {
 }
 
 func Finally() {
-	for _, cli := range _clis {
+	for id, cli := range _clis {
+		zhealth.Unregister("redis." + id)
+		unregisterMetrics(id)
 		_ = cli.Close()
 	}
-	for _, clu := range _clus {
+	for id, clu := range _clus {
+		zhealth.Unregister("redis." + id)
+		unregisterMetrics(id)
 		_ = clu.Close()
 	}
 }
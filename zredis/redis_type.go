@@ -15,19 +15,19 @@ const (
 
 type Config struct {
 	Id   string // optional
-	Mode string // optional
+	Mode string `valid:"oneof=single sentinel cluster sentinel-cluster"` // optional
 
 	// 单机模式
-	Addr string // must
+	Addr string `valid:"hostport"` // must
 	DB   int    // optional
 
 	// 集群模式
-	Addrs          []string // must
+	Addrs          []string `valid:"required_if=Mode cluster,hostport"` // must
 	ReadPreference string   // must
 
 	// 哨兵模式
-	MasterName       string   // must
-	SentinelAddrs    []string // must
+	MasterName       string   `valid:"required_if=Mode sentinel sentinel-cluster"`          // must
+	SentinelAddrs    []string `valid:"required_if=Mode sentinel sentinel-cluster,hostport"` // must
 	SentinelPassword string   // optional
 	// DB int                 // optional
 	// ReadPreference string  // optional
@@ -44,6 +44,7 @@ type Config struct {
 	PoolTimeout        time.Duration // optional
 	IdleTimeout        time.Duration // optional
 	IdleCheckFrequency time.Duration // optional
+	HealthLevel        string        `valid:"oneof=critical noncritical none"` // optional, 参考 zhealth.LevelCritical
 }
 
 func (c Config) Default() Config {
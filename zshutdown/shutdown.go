@@ -0,0 +1,119 @@
+package zshutdown
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Coordinator 记录正在运行的任务，应用退出时等待所有任务结束
+type Coordinator struct {
+	mu      sync.Mutex
+	seq     int64
+	running map[int64]string
+	changed chan struct{}
+}
+
+func New() *Coordinator {
+	return &Coordinator{
+		running: make(map[int64]string, 16),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Add 登记一个正在运行的任务，任务结束时必须调用返回的函数
+func (c *Coordinator) Add(name string) (done func()) {
+	c.mu.Lock()
+	c.seq++
+	id := c.seq
+	c.running[id] = name
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.running, id)
+			c.mu.Unlock()
+			select {
+			case c.changed <- struct{}{}:
+			default:
+			}
+		})
+	}
+}
+
+// Go 在新的 goroutine 中运行 f，并在其返回前一直处于登记状态
+func (c *Coordinator) Go(name string, f func()) {
+	done := c.Add(name)
+	go func() {
+		defer done()
+		f()
+	}()
+}
+
+// Running 返回仍在运行的任务名称，同名任务合并显示数量
+func (c *Coordinator) Running() []string {
+	c.mu.Lock()
+	counts := make(map[string]int, len(c.running))
+	for _, name := range c.running {
+		counts[name]++
+	}
+	c.mu.Unlock()
+
+	names := make([]string, 0, len(counts))
+	for name, n := range counts {
+		if n > 1 {
+			name = fmt.Sprintf("%s(%d)", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Coordinator) empty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.running) == 0
+}
+
+// Wait 等待所有任务结束，超时后返回未结束的任务名称
+func (c *Coordinator) Wait(timeout time.Duration) []string {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for !c.empty() {
+		select {
+		case <-c.changed:
+		case <-timer.C:
+			return c.Running()
+		}
+	}
+
+	return nil
+}
+
+var _default = New()
+
+func Default() *Coordinator {
+	return _default
+}
+
+func Add(name string) (done func()) {
+	return _default.Add(name)
+}
+
+func Go(name string, f func()) {
+	_default.Go(name, f)
+}
+
+func Running() []string {
+	return _default.Running()
+}
+
+func Wait(timeout time.Duration) []string {
+	return _default.Wait(timeout)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
 	"github.com/yyliziqiu/zlib/zutil"
 )
 
@@ -24,7 +25,8 @@ func Persist(ctx context.Context, persistencesFunc func() []Persistence) error {
 	}
 
 	for _, persistence := range persistences {
-		go runSave(ctx, persistence)
+		p := persistence
+		zshutdown.Go("snap-"+p.Name(), func() { runSave(ctx, p) })
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
 	"github.com/yyliziqiu/zlib/zutil"
 )
 
@@ -47,7 +48,8 @@ func Watch(ctx context.Context, handlersFunc func() []Handler) error {
 	}
 
 	for _, handler := range handlers {
-		go runWatchSave(ctx, handler)
+		h := handler
+		zshutdown.Go("snap-"+handlerName(h), func() { runWatchSave(ctx, h) })
 	}
 
 	return nil
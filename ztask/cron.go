@@ -3,11 +3,13 @@ package ztask
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
 )
 
 type CronTask struct {
@@ -16,31 +18,85 @@ type CronTask struct {
 	Cmd  func()
 }
 
+type cronEntry struct {
+	runner *cron.Cron
+	id     cron.EntryID
+	task   CronTask
+}
+
+var (
+	_cronMu      sync.Mutex
+	_cronEntries = make(map[string]cronEntry)
+)
+
 func RunCronTasks(ctx context.Context, loc *time.Location, tasksFunc func() []CronTask) {
+	done := zshutdown.Add("cron-task")
+	defer done()
+
 	cronRunner := cron.New(
 		cron.WithSeconds(),
 		cron.WithLocation(location(loc)),
 	)
 
+	var names []string
+	defer func() {
+		_cronMu.Lock()
+		for _, name := range names {
+			delete(_cronEntries, name)
+		}
+		_cronMu.Unlock()
+	}()
+
 	for _, task := range tasksFunc() {
 		if task.Spec == "" {
 			continue
 		}
-		_, err := cronRunner.AddFunc(task.Spec, task.Cmd)
+		id, err := cronRunner.AddFunc(task.Spec, cronCmd(task))
 		if err != nil {
 			zlog.Errorf("Add cron task failed, error: %v.", err)
 			return
 		}
+		_cronMu.Lock()
+		_cronEntries[task.Name] = cronEntry{runner: cronRunner, id: id, task: task}
+		_cronMu.Unlock()
+		names = append(names, task.Name)
 		zlog.Infof("Add cron task: %s.", task.Name)
 	}
 
 	cronRunner.Start()
 	zlog.Info("Cron task started.")
 	<-ctx.Done()
-	cronRunner.Stop()
+	<-cronRunner.Stop().Done()
 	zlog.Info("Cron task exit.")
 }
 
+// UpdateCronTasks 按名称修改正在运行的定时任务的执行周期，Spec 为空或未变化的任务保持不变
+func UpdateCronTasks(configs []CronTask) error {
+	_cronMu.Lock()
+	defer _cronMu.Unlock()
+
+	for _, config := range configs {
+		entry, ok := _cronEntries[config.Name]
+		if !ok || config.Spec == "" || config.Spec == entry.task.Spec {
+			continue
+		}
+
+		id, err := entry.runner.AddFunc(config.Spec, cronCmd(entry.task))
+		if err != nil {
+			return fmt.Errorf("update cron task [%s] failed [%v]", config.Name, err)
+		}
+		entry.runner.Remove(entry.id)
+
+		entry.id = id
+		entry.task.Spec = config.Spec
+		_cronEntries[config.Name] = entry
+
+		zlog.Infof("Update cron task: %s, spec: %s.", config.Name, config.Spec)
+	}
+
+	return nil
+}
+
 func location(loc *time.Location) *time.Location {
 	if loc != nil {
 		return loc
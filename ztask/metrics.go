@@ -0,0 +1,39 @@
+package ztask
+
+import (
+	"time"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+const (
+	taskTypeCron = "cron"
+	taskTypeOnce = "once"
+)
+
+var (
+	_runsTotal = zmetrics.NewCounter(
+		"ztask_runs_total",
+		"Total number of task runs.",
+		"type", "task",
+	)
+	_runDuration = zmetrics.NewHistogram(
+		"ztask_run_duration_seconds",
+		"Task run duration in seconds.",
+		[]float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600},
+		"type", "task",
+	)
+)
+
+// observe 统计任务执行次数和耗时
+func observe(typ string, name string, start time.Time) {
+	_runsTotal.Inc(typ, name)
+	_runDuration.Observe(time.Since(start).Seconds(), typ, name)
+}
+
+func cronCmd(task CronTask) func() {
+	return func() {
+		defer observe(taskTypeCron, task.Name, time.Now())
+		task.Cmd()
+	}
+}
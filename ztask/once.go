@@ -3,8 +3,10 @@ package ztask
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
 )
 
 type OnceTask struct {
@@ -19,7 +21,11 @@ func StartOnceTasks(ctx context.Context, tasksFunc func() []OnceTask) {
 			continue
 		}
 		for i := 0; i < task.GON; i++ {
-			go task.Cmd(ctx)
+			cmd, name := task.Cmd, task.Name
+			zshutdown.Go("once-task-"+name, func() {
+				defer observe(taskTypeOnce, name, time.Now())
+				cmd(ctx)
+			})
 		}
 		zlog.Infof("Add once task: %s (%d).", task.Name, task.GON)
 	}
@@ -0,0 +1,51 @@
+package ztrace
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Carrier 传递 traceparent 和 tracestate 的载体，如 HTTP 请求头、kafka 消息头
+type Carrier interface {
+	Get(key string) string
+	Set(key string, value string)
+}
+
+// HeaderCarrier 以 http.Header 作为载体
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Inject 将 ctx 中的 SpanContext 写入载体
+func Inject(ctx context.Context, carrier Carrier) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		carrier.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Extract 从载体中解析上游的 SpanContext 并保存到 ctx，解析失败时返回原 ctx
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	sc, err := ParseTraceparent(carrier.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+
+	state := strings.TrimSpace(carrier.Get(TracestateHeader))
+	if len(state) <= maxTracestateLength {
+		sc.TraceState = state
+	}
+
+	return ContextWithRemote(ctx, sc)
+}
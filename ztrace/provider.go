@@ -0,0 +1,272 @@
+package ztrace
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ExporterNone = "none"
+	ExporterFile = "file"
+	ExporterHTTP = "http"
+)
+
+// 根 span 的采样方式
+const (
+	SamplerRatio  = "ratio"  // 按 SampleRatio 采样
+	SamplerAlways = "always" // 全部采样
+	SamplerNever  = "never"  // 不采样，只传播上游的采样决定
+)
+
+type Config struct {
+	ServiceName   string            `valid:"required_if=Exporter file http"` // must
+	Exporter      string            `valid:"oneof=none file http"`           // optional, 默认为 none，只传播不导出
+	Path          string            `valid:"required_if=Exporter file"`      // optional, Exporter 为 file 时必须
+	Endpoint      string            `valid:"required_if=Exporter http,url"`  // optional, Exporter 为 http 时必须
+	Headers       map[string]string // optional, Exporter 为 http 时附加的请求头
+	Timeout       time.Duration     // optional, 单次导出的超时时间
+	Sampler       string            `valid:"oneof=ratio always never"` // optional, 默认为 ratio
+	SampleRatio   float64           `valid:"min=0,max=1"`              // optional, Sampler 为 ratio 时根 span 的采样率，为 0 时视为 1，关闭采样使用 SamplerNever
+	BatchSize     int               // optional
+	QueueSize     int               // optional, 待导出 span 的最大数量，超出时丢弃
+	FlushInterval time.Duration     // optional
+	OnError       func(err error)   `json:"-"` // optional, 导出失败时调用，默认输出到标准错误
+}
+
+func (c Config) Default() Config {
+	if c.Exporter == "" {
+		c.Exporter = ExporterNone
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Sampler == "" {
+		c.Sampler = SamplerRatio
+	}
+	if c.SampleRatio == 0 {
+		c.SampleRatio = 1
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 512
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 4096
+	}
+	if c.FlushInterval == 0 {
+		c.FlushInterval = 5 * time.Second
+	}
+	if c.OnError == nil {
+		c.OnError = func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "Export spans failed, error: %v.\n", err)
+		}
+	}
+	return c
+}
+
+// ratio 返回根 span 的实际采样率
+func (c Config) ratio() float64 {
+	switch c.Sampler {
+	case SamplerAlways:
+		return 1
+	case SamplerNever:
+		return 0
+	default:
+		return c.SampleRatio
+	}
+}
+
+// provider 缓存已结束的 span，按批次导出
+type provider struct {
+	config   Config
+	exporter Exporter
+	spans    []SpanData
+	mu       sync.Mutex
+
+	ratio   atomic.Uint64 // math.Float64bits(SampleRatio)
+	dropped atomic.Int64
+
+	exportMu sync.Mutex
+	flushCh  chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+var _provider = newProvider()
+
+func newProvider() *provider {
+	p := &provider{}
+	p.ratio.Store(math.Float64bits(1))
+	return p
+}
+
+// Init 按配置创建 Exporter 并开始导出
+func Init(config Config) error {
+	config = config.Default()
+
+	var exporter Exporter
+	switch config.Exporter {
+	case ExporterFile:
+		fe, err := NewFileExporter(config.ServiceName, config.Path)
+		if err != nil {
+			return err
+		}
+		exporter = fe
+	case ExporterHTTP:
+		exporter = NewHTTPExporter(config.ServiceName, config.Endpoint, config.Headers, config.Timeout)
+	case ExporterNone:
+	default:
+		return fmt.Errorf("not support trace exporter [%s]", config.Exporter)
+	}
+
+	_provider.start(config, exporter)
+
+	return nil
+}
+
+// SetExporter 使用自定义的 Exporter，如 MemoryExporter
+func SetExporter(config Config, exporter Exporter) {
+	_provider.start(config.Default(), exporter)
+}
+
+// Flush 立即导出所有待导出的 span
+func Flush() {
+	_provider.flush()
+}
+
+// Dropped 返回因队列已满而丢弃的 span 数量
+func Dropped() int64 {
+	return _provider.dropped.Load()
+}
+
+// Finally 导出剩余的 span 并关闭 Exporter
+func Finally() {
+	_provider.stop()
+}
+
+func (p *provider) start(config Config, exporter Exporter) {
+	p.stop()
+
+	p.mu.Lock()
+	p.config = config
+	p.exporter = exporter
+	p.spans = nil
+	p.ratio.Store(math.Float64bits(config.ratio()))
+	if exporter != nil {
+		p.flushCh = make(chan struct{}, 1)
+		p.stopCh = make(chan struct{})
+		p.doneCh = make(chan struct{})
+	}
+	p.mu.Unlock()
+
+	if exporter == nil {
+		return
+	}
+
+	go p.loop(p.flushCh, p.stopCh, p.doneCh, config.FlushInterval)
+}
+
+func (p *provider) loop(flushCh chan struct{}, stopCh chan struct{}, doneCh chan struct{}, interval time.Duration) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-flushCh:
+			p.flush()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (p *provider) stop() {
+	if p.stopCh != nil {
+		close(p.stopCh)
+		<-p.doneCh
+		p.stopCh = nil
+	}
+
+	p.flush()
+
+	p.mu.Lock()
+	exporter, timeout := p.exporter, p.config.Timeout
+	p.exporter = nil
+	p.mu.Unlock()
+
+	if exporter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_ = exporter.Shutdown(ctx)
+	}
+}
+
+func (p *provider) enqueue(span SpanData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.exporter == nil {
+		return
+	}
+	if len(p.spans) >= p.config.QueueSize {
+		p.dropped.Add(1)
+		return
+	}
+
+	p.spans = append(p.spans, span)
+	if len(p.spans) >= p.config.BatchSize {
+		select {
+		case p.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *provider) flush() {
+	p.exportMu.Lock()
+	defer p.exportMu.Unlock()
+
+	p.mu.Lock()
+	spans, exporter, config := p.spans, p.exporter, p.config
+	p.spans = nil
+	p.mu.Unlock()
+
+	if exporter == nil || len(spans) == 0 {
+		return
+	}
+
+	for i := 0; i < len(spans); i += config.BatchSize {
+		j := i + config.BatchSize
+		if j > len(spans) {
+			j = len(spans)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		err := exporter.Export(ctx, spans[i:j])
+		cancel()
+		if err != nil {
+			config.OnError(err)
+		}
+	}
+}
+
+// sample 按 trace id 决定根 span 是否采样，同一 trace id 的结果总是相同
+func sample(id TraceID) bool {
+	ratio := math.Float64frombits(_provider.ratio.Load())
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	return binary.BigEndian.Uint64(id[8:]) < uint64(ratio*math.MaxUint64)
+}
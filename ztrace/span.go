@@ -0,0 +1,177 @@
+package ztrace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// span 类型，取值与 OTLP 一致
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+	SpanKindClient   = 3
+	SpanKindProducer = 4
+	SpanKindConsumer = 5
+)
+
+// span 状态，取值与 OTLP 一致
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// SpanData 已结束的 span，交给 Exporter 导出
+type SpanData struct {
+	Name          string
+	Kind          int
+	SpanContext   SpanContext
+	ParentSpanID  SpanID
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	StatusCode    int
+	StatusMessage string
+}
+
+// Span 一次操作的耗时和属性，必须调用 End 结束
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type spanKey struct{}
+
+// Start 创建 span，ctx 中存在 span 或上游传入的 SpanContext 时作为其子 span，
+// 返回的 ctx 中包含新创建的 span
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		if sample(sc.TraceID) {
+			sc.Flags = FlagSampled
+		}
+	}
+
+	span := &Span{
+		data: SpanData{
+			Name:         name,
+			Kind:         kind,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			StartTime:    time.Now(),
+			Attributes:   make(map[string]any, 8),
+		},
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext 返回 ctx 中的 span，不存在时返回 nil，nil span 的方法均可安全调用
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+type remoteKey struct{}
+
+// ContextWithRemote 保存上游传入的 SpanContext，之后在 ctx 上创建的 span 均为其子 span
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext 返回 ctx 中 span 的 SpanContext，没有 span 时返回上游传入的 SpanContext
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.Context()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// TraceIDFromContext 返回 ctx 中的 trace id，不存在时返回空字符串
+func TraceIDFromContext(ctx context.Context) string {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID.String()
+}
+
+// SpanIDFromContext 返回 ctx 中的 span id，不存在时返回空字符串
+func SpanIDFromContext(ctx context.Context) string {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.SpanID.String()
+}
+
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttribute 设置属性，value 支持 string、bool、整数和浮点数，其他类型按字符串导出
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+	s.mu.Unlock()
+}
+
+func (s *Span) SetStatus(code int, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.ended {
+		s.data.StatusCode = code
+		s.data.StatusMessage = message
+	}
+	s.mu.Unlock()
+}
+
+// SetError err 不为 nil 时将状态设置为 StatusError
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束 span，已采样的 span 会交给 Exporter 导出，重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled() {
+		_provider.enqueue(data)
+	}
+}
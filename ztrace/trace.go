@@ -0,0 +1,130 @@
+package ztrace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	// FlagSampled traceparent 中表示已采样的标志位
+	FlagSampled byte = 0x01
+
+	// tracestate 的最大长度，超出时丢弃
+	maxTracestateLength = 512
+)
+
+var InvalidTraceparentError = errors.New("invalid traceparent")
+
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// SpanContext 需要跨进程传播的 span 信息，对应 W3C traceparent 和 tracestate
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	Remote     bool // 是否从上游请求中解析得到
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent 返回 W3C traceparent，如 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent 解析 W3C traceparent，兼容更高版本中追加的字段
+func ParseTraceparent(s string) (SpanContext, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || (len(s) > 55 && (s[:2] == "00" || s[55] != '-')) {
+		return SpanContext{}, InvalidTraceparentError
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, InvalidTraceparentError
+	}
+
+	version, err := decodeHex(s[0:2], 1)
+	if err != nil || version[0] == 0xff {
+		return SpanContext{}, InvalidTraceparentError
+	}
+
+	var sc SpanContext
+
+	traceID, err := decodeHex(s[3:35], 16)
+	if err != nil {
+		return SpanContext{}, InvalidTraceparentError
+	}
+	copy(sc.TraceID[:], traceID)
+
+	spanID, err := decodeHex(s[36:52], 8)
+	if err != nil {
+		return SpanContext{}, InvalidTraceparentError
+	}
+	copy(sc.SpanID[:], spanID)
+
+	flags, err := decodeHex(s[53:55], 1)
+	if err != nil {
+		return SpanContext{}, InvalidTraceparentError
+	}
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, InvalidTraceparentError
+	}
+
+	return sc, nil
+}
+
+// decodeHex 只接受小写十六进制
+func decodeHex(s string, n int) ([]byte, error) {
+	if len(s) != n*2 || strings.ToLower(s) != s {
+		return nil, InvalidTraceparentError
+	}
+	return hex.DecodeString(s)
+}
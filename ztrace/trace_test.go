@@ -0,0 +1,91 @@
+package ztrace
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	s := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Sampled() || sc.Traceparent() != s {
+		t.Errorf("unexpected span context: %s", sc.Traceparent())
+	}
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	}
+	for _, s := range invalids {
+		if _, err = ParseTraceparent(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+
+	if _, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("future version should be accepted: %v", err)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	exporter := NewMemoryExporter()
+	SetExporter(Config{ServiceName: "test"}, exporter)
+	defer Finally()
+
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	incoming.Set(TracestateHeader, "vendor=value")
+
+	ctx := Extract(context.Background(), HeaderCarrier(incoming))
+	ctx, server := Start(ctx, "server", SpanKindServer)
+	clientCtx, client := Start(ctx, "client", SpanKindClient)
+
+	outgoing := http.Header{}
+	Inject(clientCtx, HeaderCarrier(outgoing))
+	client.End()
+	server.End()
+	Flush()
+
+	if !strings.HasPrefix(outgoing.Get(TraceparentHeader), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+client.Context().SpanID.String()) {
+		t.Errorf("unexpected traceparent: %s", outgoing.Get(TraceparentHeader))
+	}
+	if outgoing.Get(TracestateHeader) != "vendor=value" {
+		t.Errorf("unexpected tracestate: %s", outgoing.Get(TracestateHeader))
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].ParentSpanID != server.Context().SpanID || spans[1].ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("unexpected parents: %s, %s", spans[0].ParentSpanID, spans[1].ParentSpanID)
+	}
+
+	bs, err := MarshalOTLP("test", spans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bs), `"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("unexpected otlp json: %s", bs)
+	}
+}
+
+func TestSamplerNever(t *testing.T) {
+	SetExporter(Config{ServiceName: "test", Sampler: SamplerNever}, NewMemoryExporter())
+	defer Finally()
+
+	_, span := Start(context.Background(), "root", SpanKindServer)
+	defer span.End()
+
+	if span.Context().Sampled() {
+		t.Error("root span should not be sampled")
+	}
+}
@@ -0,0 +1,285 @@
+package zvalid
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TagName 校验规则所在的结构体标签，多个规则以逗号分隔，如：
+//
+//	Addr  string        `valid:"required,hostport"`
+//	Mode  string        `valid:"oneof=single cluster"`
+//	Addrs []string      `valid:"required_if=Mode cluster,hostport"`
+//	Wait  time.Duration `valid:"min=1s,max=1m"`
+//
+// 支持的规则：
+//   - required：不能为零值
+//   - required_if=Field v1 v2：同级字段 Field 的值为 v1 或 v2 时不能为零值
+//   - min=n、max=n：数值比较大小，字符串、切片、map 比较长度，time.Duration 比较时长
+//   - oneof=v1 v2：值必须是其中之一
+//   - oneofci=v1 v2：同 oneof，但不区分大小写，用于 log level 等本身不区分大小写的值
+//   - url：必须是带 scheme 和 host 的 URL
+//   - hostport：必须是 host:port 格式，host 可以为空
+//
+// 对于元素为基本类型的切片，required、required_if、min、max 作用于切片本身，其余规则作用于每个元素。
+// oneof、oneofci、url、hostport 不校验零值，required_if 比较值时不区分大小写。结构体及结构体切片字段会被递归校验。
+const TagName = "valid"
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Path    string
+	Rule    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Path, e.Message)
+}
+
+// Errors 所有字段的校验错误
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate 按结构体标签校验 v，返回所有字段的校验错误，校验通过时返回 nil
+func Validate(v any) error {
+	var errs Errors
+	validateValue(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateValue(val reflect.Value, path string, errs *Errors) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		validateStruct(val, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			validateValue(val.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func validateStruct(val reflect.Value, path string, errs *Errors) {
+	typ := val.Type()
+	if typ == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		fv := val.Field(i)
+		tag := field.Tag.Get(TagName)
+		if tag != "" && tag != "-" {
+			for _, rule := range strings.Split(tag, ",") {
+				validateRule(val, fv, fieldPath, strings.TrimSpace(rule), errs)
+			}
+		}
+
+		if tag != "-" && isNested(fv.Type()) {
+			validateValue(fv, fieldPath, errs)
+		}
+	}
+}
+
+// isNested 是否需要递归校验，不递归指针字段，避免进入 *logrus.Logger 等运行时对象
+func isNested(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+func validateRule(parent reflect.Value, fv reflect.Value, path string, rule string, errs *Errors) {
+	if rule == "" {
+		return
+	}
+
+	name, param, _ := strings.Cut(rule, "=")
+
+	var message string
+	switch name {
+	case "required":
+		if fv.IsZero() || isEmpty(fv) {
+			message = "is required"
+		}
+	case "required_if":
+		message = checkRequiredIf(parent, fv, param)
+	case "min", "max":
+		message = checkRange(fv, name, param)
+	case "oneof", "oneofci", "url", "hostport":
+		message = checkEach(fv, name, param)
+	default:
+		message = fmt.Sprintf("has unknown rule [%s]", name)
+	}
+
+	if message != "" {
+		*errs = append(*errs, FieldError{Path: path, Rule: name, Message: message})
+	}
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return fv.Len() == 0
+	default:
+		return false
+	}
+}
+
+func checkRequiredIf(parent reflect.Value, fv reflect.Value, param string) string {
+	items := strings.Fields(param)
+	if len(items) < 2 {
+		return fmt.Sprintf("has invalid rule [required_if=%s]", param)
+	}
+
+	other := parent.FieldByName(items[0])
+	if !other.IsValid() {
+		return fmt.Sprintf("has invalid rule [required_if=%s], field %s not found", param, items[0])
+	}
+
+	actual := fmt.Sprintf("%v", other.Interface())
+	for _, expected := range items[1:] {
+		if strings.EqualFold(actual, expected) {
+			if fv.IsZero() || isEmpty(fv) {
+				return fmt.Sprintf("is required when %s is %s", items[0], actual)
+			}
+			return ""
+		}
+	}
+
+	return ""
+}
+
+var _durationType = reflect.TypeOf(time.Duration(0))
+
+func checkRange(fv reflect.Value, name string, param string) string {
+	var (
+		actual float64
+		what   string
+	)
+
+	switch {
+	case fv.Type() == _durationType:
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return fmt.Sprintf("has invalid rule [%s=%s]", name, param)
+		}
+		if (name == "min" && fv.Int() < int64(d)) || (name == "max" && fv.Int() > int64(d)) {
+			return fmt.Sprintf("must be %s %s", rangeWord(name), param)
+		}
+		return ""
+	case fv.Kind() == reflect.String || fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map || fv.Kind() == reflect.Array:
+		actual, what = float64(fv.Len()), " in length"
+	case fv.CanInt():
+		actual = float64(fv.Int())
+	case fv.CanUint():
+		actual = float64(fv.Uint())
+	case fv.CanFloat():
+		actual = fv.Float()
+	default:
+		return fmt.Sprintf("does not support rule [%s]", name)
+	}
+
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Sprintf("has invalid rule [%s=%s]", name, param)
+	}
+
+	if (name == "min" && actual < limit) || (name == "max" && actual > limit) {
+		return fmt.Sprintf("must be %s %s%s", rangeWord(name), param, what)
+	}
+
+	return ""
+}
+
+func rangeWord(name string) string {
+	if name == "min" {
+		return "at least"
+	}
+	return "at most"
+}
+
+// checkEach 校验值，切片时校验每个元素
+func checkEach(fv reflect.Value, name string, param string) string {
+	if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+		for i := 0; i < fv.Len(); i++ {
+			message := checkOne(fv.Index(i), name, param)
+			if message != "" {
+				return fmt.Sprintf("[%d] %s", i, message)
+			}
+		}
+		return ""
+	}
+	return checkOne(fv, name, param)
+}
+
+func checkOne(fv reflect.Value, name string, param string) string {
+	if fv.IsZero() {
+		return ""
+	}
+
+	value := fmt.Sprintf("%v", fv.Interface())
+
+	switch name {
+	case "oneof":
+		for _, option := range strings.Fields(param) {
+			if value == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %s", param, value)
+	case "oneofci":
+		for _, option := range strings.Fields(param) {
+			if strings.EqualFold(value, option) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s] (case-insensitive), got %s", param, value)
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Sprintf("must be a valid URL, got %s", value)
+		}
+	case "hostport":
+		_, port, err := net.SplitHostPort(value)
+		if err != nil {
+			return fmt.Sprintf("must be host:port, got %s", value)
+		}
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 0 || n > 65535 {
+			return fmt.Sprintf("must have a valid port, got %s", value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,65 @@
+package zvalid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDB struct {
+	Type string `valid:"oneof=mysql postgres"`
+	DSN  string `valid:"required"`
+}
+
+type testConfig struct {
+	Name  string        `valid:"required,min=2"`
+	Addr  string        `valid:"hostport"`
+	Mode  string        `valid:"oneof=single cluster"`
+	Level string        `valid:"oneofci=debug info"`
+	Addrs []string      `valid:"required_if=Mode cluster,hostport"`
+	Hosts []string      `valid:"url"`
+	Wait  time.Duration `valid:"min=1s,max=1m"`
+	DB    []testDB
+}
+
+func TestValidate(t *testing.T) {
+	c := testConfig{
+		Name:  "app",
+		Addr:  ":80",
+		Mode:  "single",
+		Level: "INFO",
+		Hosts: []string{"http://127.0.0.1:9200"},
+		Wait:  time.Second,
+		DB:    []testDB{{Type: "mysql", DSN: "root@tcp(127.0.0.1)/db"}},
+	}
+	err := Validate(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	c := testConfig{
+		Name:  "a",
+		Addr:  "127.0.0.1",
+		Mode:  "cluster",
+		Hosts: []string{"127.0.0.1:9200"},
+		Wait:  2 * time.Minute,
+		DB:    []testDB{{Type: "mysql", DSN: "dsn"}, {Type: "sqlite"}},
+	}
+	err := Validate(c)
+
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	paths := make([]string, 0, len(errs))
+	for _, e := range errs {
+		paths = append(paths, e.Path+":"+e.Rule)
+	}
+	expected := "Name:min,Addr:hostport,Addrs:required_if,Hosts:url,Wait:max,DB[1].Type:oneof,DB[1].DSN:required"
+	if strings.Join(paths, ",") != expected {
+		t.Fatalf("unexpected errors %v", err)
+	}
+}
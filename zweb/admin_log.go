@@ -0,0 +1,68 @@
+package zweb
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zweb/zresponse"
+)
+
+// LogLevelRoutes 挂载 GET/PUT /admin/log/level 接口，用于查看和修改日志等级。
+// 接口没有鉴权，应只在内网端口上挂载，或使用 GetLogLevel、PutLogLevel 挂载到带鉴权的路由组
+func LogLevelRoutes(engine *gin.Engine) {
+	engine.GET("/admin/log/level", GetLogLevel)
+	engine.PUT("/admin/log/level", PutLogLevel)
+}
+
+type logLevelResult struct {
+	Loggers  map[string]string `json:"loggers"`
+	RevertAt string            `json:"revert_at,omitempty"`
+}
+
+func newLogLevelResult() logLevelResult {
+	result := logLevelResult{Loggers: zlog.Levels()}
+	if at := zlog.RevertAt(); !at.IsZero() {
+		result.RevertAt = at.Format(time.RFC3339)
+	}
+	return result
+}
+
+func GetLogLevel(ctx *gin.Context) {
+	zresponse.Result(ctx, newLogLevelResult())
+}
+
+type logLevelForm struct {
+	Level    string `json:"level" form:"level" binding:"required"`
+	Logger   string `json:"logger" form:"logger"`     // 为空时修改所有日志
+	Duration string `json:"duration" form:"duration"` // 如 10m，为空时不恢复
+}
+
+// PutLogLevel 修改日志等级，请求体如 {"level": "debug", "logger": "web-access", "duration": "10m"}
+func PutLogLevel(ctx *gin.Context) {
+	var form logLevelForm
+	if !BindForm(ctx, &form, true) {
+		return
+	}
+
+	var duration time.Duration
+	if form.Duration != "" {
+		d, err := time.ParseDuration(form.Duration)
+		if err != nil || d < 0 {
+			zresponse.ErrorString(ctx, "invalid duration "+form.Duration)
+			return
+		}
+		duration = d
+	}
+
+	err := zlog.SetLevels(form.Logger, form.Level, duration)
+	if err != nil {
+		zresponse.ErrorString(ctx, err.Error())
+		return
+	}
+
+	zlog.Warnf("Log level changed by admin API, logger: %s, level: %s, duration: %s.", form.Logger, form.Level, duration)
+
+	zresponse.Result(ctx, newLogLevelResult())
+}
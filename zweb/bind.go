@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/yyliziqiu/zlib/zerror"
+	"github.com/yyliziqiu/zlib/zredact"
 	"github.com/yyliziqiu/zlib/zweb/zresponse"
 )
 
@@ -13,7 +14,7 @@ func BindForm(ctx *gin.Context, form interface{}, verbose bool) bool {
 	err := ctx.ShouldBind(form)
 	if err != nil {
 		if _errorLogger != nil {
-			_errorLogger.Warnf("Bind request params failed, path: %s, form: %v, error: %v.", ctx.FullPath(), form, err)
+			_errorLogger.Warnf("Bind request params failed, path: %s, form: %s, error: %v.", ctx.FullPath(), zredact.Default().Value(form), err)
 		}
 		if verbose {
 			zresponse.Error(ctx, ParamError.Wrap(err))
@@ -0,0 +1,40 @@
+package zweb
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yyliziqiu/zlib/zhealth"
+)
+
+// HealthRoutes 挂载健康检查接口：
+//   - /livez 进程存活即返回 200
+//   - /healthz 执行所有检查项，关键检查项失败时返回 503
+//   - /readyz 服务未就绪（启动中或正在退出）或关键检查项失败时返回 503
+func HealthRoutes(engine *gin.Engine) {
+	engine.GET("/livez", Livez)
+	engine.GET("/healthz", Healthz)
+	engine.GET("/readyz", Readyz)
+}
+
+func Livez(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": zhealth.StatusUp})
+}
+
+func Healthz(ctx *gin.Context) {
+	report := zhealth.Check(ctx.Request.Context())
+	if report.Healthy() {
+		ctx.JSON(http.StatusOK, report)
+	} else {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+}
+
+func Readyz(ctx *gin.Context) {
+	if !zhealth.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, zhealth.Report{Status: zhealth.StatusDown, Ready: false})
+		return
+	}
+	Healthz(ctx)
+}
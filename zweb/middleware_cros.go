@@ -2,6 +2,7 @@ package zweb
 
 import (
 	"net/http"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 
@@ -17,7 +18,7 @@ type CrosHeaders struct {
 	AllowCredentials string
 }
 
-var crosHeaders = &CrosHeaders{
+var _defaultCrosHeaders = CrosHeaders{
 	MaxAge:           "86400",
 	Origin:           "*",
 	ExposeHeaders:    "",
@@ -26,6 +27,38 @@ var crosHeaders = &CrosHeaders{
 	AllowCredentials: "false",
 }
 
+var _crosHeaders atomic.Pointer[CrosHeaders]
+
+func init() {
+	h := _defaultCrosHeaders
+	_crosHeaders.Store(&h)
+}
+
+func (h CrosHeaders) Default() CrosHeaders {
+	if h.MaxAge == "" {
+		h.MaxAge = _defaultCrosHeaders.MaxAge
+	}
+	if h.Origin == "" {
+		h.Origin = _defaultCrosHeaders.Origin
+	}
+	if h.AllowMethods == "" {
+		h.AllowMethods = _defaultCrosHeaders.AllowMethods
+	}
+	if h.AllowHeaders == "" {
+		h.AllowHeaders = _defaultCrosHeaders.AllowHeaders
+	}
+	if h.AllowCredentials == "" {
+		h.AllowCredentials = _defaultCrosHeaders.AllowCredentials
+	}
+	return h
+}
+
+// SetCrosHeaders 替换跨域响应头，未设置的字段使用默认值，可在运行时调用
+func SetCrosHeaders(h CrosHeaders) {
+	h = h.Default()
+	_crosHeaders.Store(&h)
+}
+
 // CrosMiddleware
 //
 // 允许跨域，参考： https://developer.mozilla.org/zh-CN/docs/Web/HTTP/Headers
@@ -44,6 +77,7 @@ var crosHeaders = &CrosHeaders{
 // Content-Language,
 // Content-Type.
 func CrosMiddleware(ctx *gin.Context) {
+	crosHeaders := _crosHeaders.Load()
 	ctx.Header("Access-Control-Allow-Origin", crosHeaders.Origin)
 	ctx.Header("Access-Control-Expose-Headers", crosHeaders.ExposeHeaders)
 	ctx.Header("Access-Control-Allow-Credentials", crosHeaders.AllowCredentials)
@@ -0,0 +1,45 @@
+package zweb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yyliziqiu/zlib/zmetrics"
+)
+
+var (
+	_requestsTotal = zmetrics.NewCounter(
+		"zweb_requests_total",
+		"Total number of HTTP requests handled.",
+		"method", "route", "status",
+	)
+	_requestDuration = zmetrics.NewHistogram(
+		"zweb_request_duration_seconds",
+		"HTTP request latency in seconds.",
+		nil,
+		"method", "route", "status",
+	)
+)
+
+// MetricsMiddleware 按路由和状态码统计请求数和耗时，未匹配到路由的请求记为 unmatched
+func MetricsMiddleware(ctx *gin.Context) {
+	start := time.Now()
+
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(ctx.Writer.Status())
+
+	_requestsTotal.Inc(ctx.Request.Method, route, status)
+	_requestDuration.Observe(time.Since(start).Seconds(), ctx.Request.Method, route, status)
+}
+
+// MetricsRoutes 挂载 /metrics 接口，以 Prometheus 文本格式输出所有指标
+func MetricsRoutes(engine *gin.Engine) {
+	engine.GET("/metrics", gin.WrapH(zmetrics.Handler()))
+}
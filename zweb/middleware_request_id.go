@@ -0,0 +1,51 @@
+package zweb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yyliziqiu/zlib/zlog"
+)
+
+// 请求头中的请求 id 的最大长度，超出或包含不可见字符时重新生成
+const maxRequestIdLength = 128
+
+// RequestIdMiddleware 从请求头 X-Request-Id 读取请求 id，不存在时生成，
+// 保存到 gin.Context 和请求的 context 中，并在响应头中返回
+func RequestIdMiddleware(ctx *gin.Context) {
+	id := ctx.GetHeader(zlog.RequestIdHeader)
+	if !isValidRequestId(id) {
+		id = newRequestId()
+	}
+
+	ctx.Set(zlog.RequestIdField, id)
+	ctx.Request = ctx.Request.WithContext(zlog.WithRequestId(ctx.Request.Context(), id))
+	ctx.Header(zlog.RequestIdHeader, id)
+
+	ctx.Next()
+}
+
+// GetRequestId 返回 RequestIdMiddleware 保存的请求 id
+func GetRequestId(ctx *gin.Context) string {
+	return ctx.GetString(zlog.RequestIdField)
+}
+
+func isValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	bs := make([]byte, 16)
+	_, _ = rand.Read(bs)
+	return hex.EncodeToString(bs)
+}
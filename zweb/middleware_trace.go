@@ -0,0 +1,41 @@
+package zweb
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yyliziqiu/zlib/ztrace"
+)
+
+// TraceMiddleware 从请求头中解析上游的 traceparent，并为每个请求创建 server span，
+// 处理函数中可通过 ctx.Request.Context() 获取 span
+func TraceMiddleware(ctx *gin.Context) {
+	parent := ztrace.Extract(ctx.Request.Context(), ztrace.HeaderCarrier(ctx.Request.Header))
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	spanCtx, span := ztrace.Start(parent, ctx.Request.Method+" "+route, ztrace.SpanKindServer)
+	defer span.End()
+
+	span.SetAttribute("http.method", ctx.Request.Method)
+	span.SetAttribute("http.route", route)
+	span.SetAttribute("http.target", ctx.Request.URL.RequestURI())
+	span.SetAttribute("net.peer.ip", ctx.ClientIP())
+
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttribute("http.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(ztrace.StatusError, http.StatusText(status))
+	}
+	if len(ctx.Errors) > 0 {
+		span.SetError(ctx.Errors.Last())
+	}
+}
@@ -1,31 +1,84 @@
 package zweb
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
 	"github.com/yyliziqiu/zlib/zlog"
+	"github.com/yyliziqiu/zlib/zshutdown"
 	"github.com/yyliziqiu/zlib/zweb/zresponse"
 )
 
 func Run(config Config, routes ...func(engine *gin.Engine)) error {
 	config = config.Default()
 
+	engine := newEngine(config, routes...)
+
+	return engine.Run(config.Addr)
+}
+
+// RunWithContext 启动 web 服务，ctx 取消后优雅关闭，关闭完成前阻塞
+func RunWithContext(ctx context.Context, config Config, routes ...func(engine *gin.Engine)) error {
+	config = config.Default()
+
+	done := zshutdown.Add("web-server")
+	defer done()
+
+	server := &http.Server{
+		Addr:    config.Addr,
+		Handler: newEngine(config, routes...),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(sctx)
+	if err != nil {
+		return fmt.Errorf("shutdown web server error [%v]", err)
+	}
+
+	return nil
+}
+
+func newEngine(config Config, routes ...func(engine *gin.Engine)) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	gin.DisableConsoleColor()
 
 	setGinWriter(config)
+	SetCrosHeaders(config.Cros)
 
 	engine := createEngine()
+	if config.EnableRequestId {
+		engine.Use(RequestIdMiddleware)
+	}
+	if config.EnableMetrics {
+		engine.Use(MetricsMiddleware)
+	}
+	if config.EnableTrace {
+		engine.Use(TraceMiddleware)
+	}
 	for _, v := range routes {
 		v(engine)
 	}
 
-	return engine.Run(config.Addr)
+	return engine
 }
 
 func setGinWriter(config Config) {
@@ -46,6 +99,7 @@ func setGinWriter(config Config) {
 
 func createEngine() *gin.Engine {
 	engine := gin.New()
+	engine.ContextWithFallback = true
 	engine.NoRoute(zresponse.AbortNotFound)
 	engine.NoMethod(zresponse.AbortMethodNotAllowed)
 	engine.Use(gin.LoggerWithFormatter(logFormatter))
@@ -57,6 +111,17 @@ func logFormatter(param gin.LogFormatterParams) string {
 	if param.Latency > time.Minute {
 		param.Latency = param.Latency.Truncate(time.Second)
 	}
+	if id, ok := param.Keys[zlog.RequestIdField].(string); ok {
+		return fmt.Sprintf("%3d | %13v | %15s |%-7s %#v | %s\n%s",
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			param.Path,
+			id,
+			param.ErrorMessage,
+		)
+	}
 	return fmt.Sprintf("%3d | %13v | %15s |%-7s %#v\n%s",
 		param.StatusCode,
 		param.Latency,
@@ -68,7 +133,7 @@ func logFormatter(param gin.LogFormatterParams) string {
 }
 
 func recovery(ctx *gin.Context, err interface{}) {
-	_errorLogger.Warnf("Web panic, path: %s, error: %v", ctx.FullPath(), err)
+	_errorLogger.WithContext(ctx).Warnf("Web panic, path: %s, error: %v", ctx.FullPath(), err)
 	zresponse.AbortInternalServerError(ctx)
 }
 
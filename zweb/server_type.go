@@ -1,6 +1,8 @@
 package zweb
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,10 +12,15 @@ var (
 )
 
 type Config struct {
-	Addr             string
+	Addr             string `valid:"hostport"`
 	DisableAccessLog bool
+	EnableMetrics    bool // optional, 使用 MetricsMiddleware
+	EnableTrace      bool // optional, 使用 TraceMiddleware
+	EnableRequestId  bool // optional, 使用 RequestIdMiddleware
 	ErrorLogName     string
 	AccessLogName    string
+	ShutdownTimeout  time.Duration
+	Cros             CrosHeaders
 }
 
 func (c Config) Default() Config {
@@ -26,5 +33,8 @@ func (c Config) Default() Config {
 	if c.AccessLogName == "" {
 		c.AccessLogName = "web-access"
 	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = 10 * time.Second
+	}
 	return c
 }